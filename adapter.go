@@ -0,0 +1,75 @@
+package yarf
+
+import (
+	"net/http"
+)
+
+// Wrap adds standard net/http middleware around the group dispatch, including the group MiddlewareHandler list.
+// The first one added is the outermost.
+// Wrappers added to the Yarf router run around the whole request,
+// including the global middleware, the requests not matching any route and the error responses.
+// Context.Request and Context.Response are replaced by the ones each wrapper passes down to the next handler,
+// and restored after the wrappers return.
+// Errors returned inside the wrappers are written before returning to them, so they see the error responses.
+// If a wrapper doesn't call the next handler, the group routes aren't dispatched.
+func (g *GroupRoute) Wrap(mw ...func(http.Handler) http.Handler) {
+	g.wrappers = append(g.wrappers, mw...)
+}
+
+// wrap runs a function inside the group net/http middleware wrappers.
+func (g *GroupRoute) wrap(c *Context, f func(*Context)) {
+	req, res := c.Request, c.Response
+
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Request, c.Response = r, w
+		f(c)
+	})
+	for i := len(g.wrappers) - 1; i >= 0; i-- {
+		h = g.wrappers[i](h)
+	}
+
+	h.ServeHTTP(res, req)
+	c.Request, c.Response = req, res
+}
+
+// dispatchWrapped runs the group dispatch inside the net/http middleware wrappers.
+// Errors are written by the error handlers inside the wrappers, and then returned to the outer groups.
+func (g *GroupRoute) dispatchWrapped(c *Context) (err error) {
+	g.wrap(c, func(c *Context) {
+		err = g.dispatch(c)
+		if err != nil && c.yarf != nil {
+			c.yarf.handleError(c, err)
+		}
+	})
+
+	return
+}
+
+// HTTPMiddleware exposes a list of MiddlewareHandler as standard net/http middleware,
+// so they can be used to wrap any http.Handler.
+// A new Context is created for each request, and errors returned by the middleware are written as responses,
+// in the same way DefaultErrorHandler does.
+func HTTPMiddleware(m ...MiddlewareHandler) func(http.Handler) http.Handler {
+	return new(Yarf).HTTPMiddleware(m...)
+}
+
+// HTTPMiddleware works as the HTTPMiddleware function, but the errors are handled as the Yarf router does,
+// using its error handlers, debug mode, problem details and logger.
+func (y *Yarf) HTTPMiddleware(m ...MiddlewareHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := NewContext(r, w)
+			c.yarf = y
+			if c.Writer() != w {
+				// Send the status code of responses without body
+				defer c.Writer().WriteHeaderNow()
+			}
+
+			err := dispatchMiddleware(m, c, func(c *Context) error {
+				next.ServeHTTP(c.Response, c.Request)
+				return nil
+			})
+			y.finish(c, err)
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package yarf
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// tagWrapper is a net/http middleware appending a tag to the X-Tags request and response headers.
+func tagWrapper(tag string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.Clone(r.Context())
+			r.Header.Add("X-Tags", tag)
+			w.Header().Add("X-Tags", tag)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type MockTagsResource struct {
+	Resource
+}
+
+func (r *MockTagsResource) Get(c *Context) error {
+	c.Render(c.Request.Header.Get("X-Tags") + "," + c.Request.Header.Values("X-Tags")[1])
+	return nil
+}
+
+func TestGroupWrap(t *testing.T) {
+	y := New()
+	y.Wrap(tagWrapper("outer"), tagWrapper("inner"))
+	y.Add("/tags", new(MockTagsResource))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/tags", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Body.String() != "outer,inner" {
+		t.Errorf("Request changed by wrappers should reach the resource, '%s' found", res.Body.String())
+	}
+	if len(res.Header().Values("X-Tags")) != 2 {
+		t.Errorf("Wrappers should set response headers, %v found", res.Header())
+	}
+	if req.Header.Get("X-Tags") != "" {
+		t.Error("Original request shouldn't be changed")
+	}
+}
+
+func TestGroupWrapStop(t *testing.T) {
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(401)
+		})
+	}
+
+	var log []string
+
+	g := RouteGroup("/private")
+	g.Wrap(deny)
+	g.Insert(&MockLogMiddleware{name: "group", log: &log})
+	g.Add("/", new(MockTagsResource))
+
+	y := New()
+	y.AddGroup(g)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/private", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 401 {
+		t.Errorf("Wrapper response should be sent, %d found", res.Code)
+	}
+	if len(log) > 0 {
+		t.Errorf("Group dispatch shouldn't run if the wrapper doesn't call it, %v found", log)
+	}
+}
+
+// statusWrapper is a net/http middleware logging the response status it sees.
+func statusWrapper(name string, log *[]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &statusRecorder{ResponseWriter: w, status: 200}
+			next.ServeHTTP(rec, r)
+			*log = append(*log, name+":"+strconv.Itoa(rec.status))
+		})
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func TestWrapErrorResponse(t *testing.T) {
+	var log []string
+
+	g := RouteGroup("/api")
+	g.Wrap(statusWrapper("group", &log))
+	g.Add("/missing", &MockErrorResource{err: ErrorNotFound()})
+
+	y := New()
+	y.Wrap(statusWrapper("root", &log))
+	y.AddGroup(g)
+	y.Add("/test", new(MockResource))
+
+	tests := []struct {
+		url  string
+		code int
+		log  string
+	}{
+		{"/api/missing", 404, "group:404 root:404"},
+		{"/test", 405, "root:405"},
+		{"/unmatched", 404, "root:404"},
+	}
+
+	for _, test := range tests {
+		log = nil
+
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+test.url, nil)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Code != test.code {
+			t.Errorf("Request to '%s' should return %d, %d found", test.url, test.code, res.Code)
+		}
+		if strings.Join(log, " ") != test.log {
+			t.Errorf("Wrappers should see the '%s' response status, '%s' found", test.log, strings.Join(log, " "))
+		}
+	}
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	h := HTTPMiddleware(new(MockHeaderMiddleware))(ok)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	if res.Body.String() != "OK" || res.Header().Get("X-Middleware") != "pre" {
+		t.Errorf("Middleware should run around the handler, '%s' and %v found", res.Body.String(), res.Header())
+	}
+
+	var log []string
+	h = HTTPMiddleware(&MockLogMiddleware{name: "deny", log: &log, err: ErrorNotFound()})(ok)
+
+	res = httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	if res.Code != 404 || res.Body.String() != "" {
+		t.Errorf("Middleware error should be sent as response, %d '%s' found", res.Code, res.Body.String())
+	}
+	if len(log) != 2 {
+		t.Errorf("Middleware End should run after errors, %v found", log)
+	}
+
+	wrapped := fmt.Errorf("checking token: %w", ErrorUnauthorized())
+	h = HTTPMiddleware(&MockLogMiddleware{name: "deny", log: &log, err: wrapped})(ok)
+
+	res = httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	if res.Code != 401 {
+		t.Errorf("Wrapped YErrors should keep their status code, %d found", res.Code)
+	}
+}
+
+func TestYarfHTTPMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	var handled error
+	y := New()
+	y.ProblemDetails = true
+	y.ErrorHandler = func(c *Context, err error) {
+		handled = err
+		y.DefaultErrorHandler(c, err)
+	}
+
+	h := y.HTTPMiddleware(&MockLogMiddleware{name: "deny", log: new([]string), err: ErrorForbidden()})(ok)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	if handled == nil {
+		t.Error("Middleware errors should be sent to Yarf.ErrorHandler")
+	}
+	if res.Code != 403 || res.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("Middleware errors should be rendered as problem details, %d '%s' found", res.Code, res.Header().Get("Content-Type"))
+	}
+}
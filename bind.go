@@ -0,0 +1,337 @@
+package yarf
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxBodySize is the maximum amount of bytes read from the request body by Context.Bind()
+// when Yarf.MaxBodySize isn't set.
+const DefaultMaxBodySize = 10 << 20
+
+// Bind decodes the request body into the struct pointed by v, using the decoder for the request Content-Type:
+//   - application/json, or any +json type	// encoding/json, using the json struct tags.
+//   - application/xml, text/xml, or any +xml type	// encoding/xml, using the xml struct tags.
+//   - application/x-www-form-urlencoded	// Form values, using the form struct tags.
+//   - multipart/form-data	// Form values and files, using the form struct tags.
+//
+// Form fields without a form tag use the field name. File fields can be *multipart.FileHeader or []*multipart.FileHeader.
+// Requests without body and Content-Type bind nothing.
+//
+// It returns a 400 YError if the body can't be decoded or it's larger than Yarf.MaxBodySize,
+// and a 415 YError for unsupported content types.
+// When Yarf.StrictBinding is enabled, unknown JSON and form fields are rejected as well.
+func (c *Context) Bind(v interface{}) error {
+	if err := checkBindTarget(v); err != nil {
+		return err
+	}
+
+	ct := c.Request.Header.Get("Content-Type")
+	if ct == "" && emptyBody(c.Request) {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return unsupportedMediaType(ct)
+	}
+
+	maxSize, strict := c.bindOptions()
+	if c.Request.Body != nil {
+		c.Request.Body = http.MaxBytesReader(c.Response, c.Request.Body, maxSize)
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		dec := json.NewDecoder(c.Request.Body)
+		if strict {
+			dec.DisallowUnknownFields()
+		}
+		err = dec.Decode(v)
+
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		err = xml.NewDecoder(c.Request.Body).Decode(v)
+
+	case mediaType == "application/x-www-form-urlencoded":
+		if err = c.Request.ParseForm(); err == nil {
+			err = bindValues(v, "form", c.Request.PostForm, nil, strict)
+		}
+
+	case mediaType == "multipart/form-data":
+		if err = c.Request.ParseMultipartForm(maxSize); err == nil {
+			err = bindValues(v, "form", c.Request.MultipartForm.Value, c.Request.MultipartForm.File, strict)
+		}
+
+	default:
+		return unsupportedMediaType(mediaType)
+	}
+
+	if err != nil {
+		return badRequest("Invalid request body", err)
+	}
+
+	return nil
+}
+
+// BindQuery decodes the request URL query values into the struct pointed by v, using the query struct tags.
+// Fields without a query tag use the field name.
+// It returns a 400 YError if a value can't be converted to its field type,
+// or if Yarf.StrictBinding is enabled and an unknown query param is present.
+func (c *Context) BindQuery(v interface{}) error {
+	if err := checkBindTarget(v); err != nil {
+		return err
+	}
+
+	_, strict := c.bindOptions()
+	if err := bindValues(v, "query", c.Request.URL.Query(), nil, strict); err != nil {
+		return badRequest("Invalid query params", err)
+	}
+
+	return nil
+}
+
+// BindParams decodes the route params into the struct pointed by v, using the param struct tags.
+// Fields without a param tag use the field name.
+// It returns a 400 YError if a value can't be converted to its field type.
+func (c *Context) BindParams(v interface{}) error {
+	if err := checkBindTarget(v); err != nil {
+		return err
+	}
+
+	values := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		values[p.Key] = []string{p.Value}
+	}
+
+	if err := bindValues(v, "param", values, nil, false); err != nil {
+		return badRequest("Invalid route params", err)
+	}
+
+	return nil
+}
+
+// bindOptions returns the binding configuration of the Yarf server handling the request.
+func (c *Context) bindOptions() (maxSize int64, strict bool) {
+	maxSize = DefaultMaxBodySize
+	if c.yarf != nil {
+		if c.yarf.MaxBodySize > 0 {
+			maxSize = c.yarf.MaxBodySize
+		}
+		strict = c.yarf.StrictBinding
+	}
+
+	return
+}
+
+// checkBindTarget validates that v is a non-nil pointer to a struct.
+// Wrong targets are programming errors, so they aren't returned as YError.
+func checkBindTarget(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("yarf: bind target must be a non-nil pointer to a struct, %T found", v)
+	}
+
+	return nil
+}
+
+// emptyBody returns true if the request has no body.
+func emptyBody(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0
+}
+
+// badRequest creates the 400 error returned by the bind functions.
+func badRequest(msg string, err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		msg = "Request body too large"
+	} else if err == io.EOF {
+		msg += ": empty body"
+	} else {
+		msg += ": " + err.Error()
+	}
+
+	e := ErrorBadRequest(err)
+	e.ErrorMsg = msg
+	e.ErrorBody = msg
+
+	return e
+}
+
+// unsupportedMediaType creates the 415 error returned by Bind.
+func unsupportedMediaType(mediaType string) error {
+	e := ErrorUnsupportedMediaType()
+	e.ErrorMsg = "Unsupported media type '" + mediaType + "'"
+	e.ErrorBody = e.ErrorMsg
+
+	return e
+}
+
+// fileHeaderType is the type of multipart file fields.
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// textUnmarshalerType is the type of fields decoding their own values.
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// bindValues sets the struct fields pointed by v from a list of values, and files, by the field tag name.
+// Embedded structs are flattened into the parent.
+// When strict is true, values not bound to any field return an error.
+func bindValues(v interface{}, tag string, values map[string][]string, files map[string][]*multipart.FileHeader, strict bool) error {
+	known := make(map[string]bool)
+
+	if err := bindStruct(reflect.ValueOf(v).Elem(), tag, values, files, known); err != nil {
+		return err
+	}
+
+	if strict {
+		for k := range values {
+			if !known[k] {
+				return fmt.Errorf("unknown field '%s'", k)
+			}
+		}
+		for k := range files {
+			if !known[k] {
+				return fmt.Errorf("unknown field '%s'", k)
+			}
+		}
+	}
+
+	return nil
+}
+
+// bindStruct sets the fields of a struct value, storing the names of the fields found.
+func bindStruct(rv reflect.Value, tag string, values map[string][]string, files map[string][]*multipart.FileHeader, known map[string]bool) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		fv := rv.Field(i)
+
+		name := f.Tag.Get(tag)
+		if j := strings.IndexByte(name, ','); j >= 0 {
+			name = name[:j]
+		}
+		if name == "-" {
+			continue
+		}
+
+		// Embedded structs
+		if f.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			if err := bindStruct(fv, tag, values, files, known); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Unexported fields
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		known[name] = true
+
+		switch {
+		case f.Type == fileHeaderType:
+			if fh := files[name]; len(fh) > 0 {
+				fv.Set(reflect.ValueOf(fh[0]))
+			}
+
+		case f.Type == reflect.SliceOf(fileHeaderType):
+			if fh := files[name]; len(fh) > 0 {
+				fv.Set(reflect.ValueOf(fh))
+			}
+
+		default:
+			vs, ok := values[name]
+			if !ok || len(vs) == 0 {
+				continue
+			}
+			if err := setField(fv, vs); err != nil {
+				return fmt.Errorf("field '%s': %s", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// setField converts and sets the values into a field.
+// Slices take all the values, other types take the first one.
+func setField(fv reflect.Value, values []string) error {
+	if fv.CanAddr() && fv.Addr().Type().Implements(textUnmarshalerType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values[0]))
+	}
+
+	switch fv.Kind() {
+	case reflect.Ptr:
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setField(fv.Elem(), values)
+
+	case reflect.Slice:
+		s := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setField(s.Index(i), []string{v}); err != nil {
+				return err
+			}
+		}
+		fv.Set(s)
+		return nil
+	}
+
+	return setValue(fv, values[0])
+}
+
+// setValue converts and sets a single value into a basic type field.
+func setValue(fv reflect.Value, value string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("invalid boolean value '" + value + "'")
+		}
+		fv.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("invalid integer value '" + value + "'")
+		}
+		fv.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("invalid unsigned integer value '" + value + "'")
+		}
+		fv.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return errors.New("invalid number value '" + value + "'")
+		}
+		fv.SetFloat(f)
+
+	default:
+		return errors.New("unsupported field type " + fv.Type().String())
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package yarf
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockBindBase struct {
+	ID int `json:"id" xml:"id" form:"id" query:"id" param:"id"`
+}
+
+type MockBindTarget struct {
+	MockBindBase
+	Name    string    `json:"name" xml:"name" form:"name" query:"name"`
+	Tags    []string  `json:"tags" xml:"tags" form:"tags" query:"tags"`
+	Active  *bool     `json:"active" xml:"active" form:"active" query:"active"`
+	Score   float64   `json:"score" xml:"score" form:"score" query:"score"`
+	Since   time.Time `json:"since" xml:"since" form:"since" query:"since"`
+	Ignored string    `json:"-" xml:"-" form:"-" query:"-"`
+	Page    uint      // Uses the field name
+	hidden  string    // Unexported fields are never bound
+}
+
+func newBindContext(method, url, contentType string, body string) *Context {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	c := NewContext(req, httptest.NewRecorder())
+	c.yarf = New()
+
+	return c
+}
+
+func TestBindJSON(t *testing.T) {
+	c := newBindContext("POST", "http://localhost/", "application/json; charset=utf-8", `{"id": 1, "name": "test", "tags": ["a", "b"], "active": true}`)
+
+	var v MockBindTarget
+	if err := c.Bind(&v); err != nil {
+		t.Fatalf("Bind() returned an error: %s", err)
+	}
+	if v.ID != 1 || v.Name != "test" || len(v.Tags) != 2 || v.Active == nil || !*v.Active {
+		t.Errorf("JSON body wasn't bound correctly: %+v", v)
+	}
+}
+
+func TestBindXML(t *testing.T) {
+	c := newBindContext("POST", "http://localhost/", "application/xml", `<MockBindTarget><id>2</id><name>test</name></MockBindTarget>`)
+
+	var v MockBindTarget
+	if err := c.Bind(&v); err != nil {
+		t.Fatalf("Bind() returned an error: %s", err)
+	}
+	if v.ID != 2 || v.Name != "test" {
+		t.Errorf("XML body wasn't bound correctly: %+v", v)
+	}
+}
+
+func TestBindForm(t *testing.T) {
+	c := newBindContext("POST", "http://localhost/", "application/x-www-form-urlencoded", "id=3&name=test&tags=a&tags=b&active=false&score=1.5&since=2020-01-02T03:04:05Z&Page=7&Ignored=x&hidden=x")
+
+	var v MockBindTarget
+	if err := c.Bind(&v); err != nil {
+		t.Fatalf("Bind() returned an error: %s", err)
+	}
+	if v.ID != 3 || v.Name != "test" || len(v.Tags) != 2 || v.Tags[1] != "b" || v.Active == nil || *v.Active || v.Score != 1.5 || v.Page != 7 {
+		t.Errorf("Form body wasn't bound correctly: %+v", v)
+	}
+	if v.Since.Year() != 2020 {
+		t.Errorf("TextUnmarshaler fields should be decoded, %v found", v.Since)
+	}
+	if v.Ignored != "" || v.hidden != "" {
+		t.Errorf("Ignored and unexported fields shouldn't be bound: %+v", v)
+	}
+}
+
+type MockBindUpload struct {
+	Name  string                  `form:"name"`
+	File  *multipart.FileHeader   `form:"file"`
+	Files []*multipart.FileHeader `form:"files"`
+}
+
+func TestBindMultipart(t *testing.T) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	mw.WriteField("name", "upload")
+	fw, _ := mw.CreateFormFile("file", "a.txt")
+	fw.Write([]byte("content"))
+	mw.CreateFormFile("files", "b.txt")
+	mw.CreateFormFile("files", "c.txt")
+	mw.Close()
+
+	c := newBindContext("POST", "http://localhost/", mw.FormDataContentType(), body.String())
+
+	var v MockBindUpload
+	if err := c.Bind(&v); err != nil {
+		t.Fatalf("Bind() returned an error: %s", err)
+	}
+	if v.Name != "upload" {
+		t.Errorf("Multipart value wasn't bound, '%s' found", v.Name)
+	}
+	if v.File == nil || v.File.Filename != "a.txt" || v.File.Size != 7 {
+		t.Errorf("Multipart file wasn't bound correctly: %+v", v.File)
+	}
+	if len(v.Files) != 2 {
+		t.Errorf("Multipart files should be bound as a list, %d found", len(v.Files))
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		code        int
+	}{
+		{"application/json", `{"id": "text"}`, 400},
+		{"application/json", `{"id": 1`, 400},
+		{"application/json", ``, 400},
+		{"application/xml", `<broken`, 400},
+		{"application/x-www-form-urlencoded", "id=text", 400},
+		{"multipart/form-data", "no boundary", 400},
+		{"text/csv", "a,b", 415},
+		{"invalid;;", "a,b", 415},
+		{"", "body without type", 415},
+	}
+
+	for _, test := range tests {
+		c := newBindContext("POST", "http://localhost/", test.contentType, test.body)
+
+		var v MockBindTarget
+		err := c.Bind(&v)
+		yerr, ok := err.(YError)
+		if !ok {
+			t.Errorf("Bind() with '%s' body '%s' should return a YError, %v found", test.contentType, test.body, err)
+			continue
+		}
+		if yerr.Code() != test.code {
+			t.Errorf("Bind() with '%s' body '%s' should return %d, %d found", test.contentType, test.body, test.code, yerr.Code())
+		}
+	}
+}
+
+func TestBindEmpty(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost/", nil)
+	c := NewContext(req, httptest.NewRecorder())
+
+	var v MockBindTarget
+	if err := c.Bind(&v); err != nil {
+		t.Errorf("Bind() without body should bind nothing, %s found", err)
+	}
+}
+
+func TestBindTarget(t *testing.T) {
+	c := newBindContext("POST", "http://localhost/", "application/json", `{}`)
+
+	var v MockBindTarget
+	for _, target := range []interface{}{nil, v, new(string), (*MockBindTarget)(nil)} {
+		err := c.Bind(target)
+		if err == nil {
+			t.Errorf("Bind() should fail for %T targets", target)
+		}
+		if _, ok := err.(YError); ok {
+			t.Errorf("Bind() should return a plain error for %T targets", target)
+		}
+	}
+}
+
+func TestBindMaxBodySize(t *testing.T) {
+	c := newBindContext("POST", "http://localhost/", "application/json", `{"name": "a very long name"}`)
+	c.yarf.MaxBodySize = 10
+
+	var v MockBindTarget
+	err := c.Bind(&v)
+	yerr, ok := err.(YError)
+	if !ok || yerr.Code() != 400 || yerr.Msg() != "Request body too large" {
+		t.Errorf("Bind() should reject bodies larger than MaxBodySize, %v found", err)
+	}
+}
+
+func TestBindStrict(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+	}{
+		{"application/json", `{"id": 1, "unknown": true}`},
+		{"application/x-www-form-urlencoded", "id=1&unknown=true"},
+	}
+
+	for _, test := range tests {
+		var v MockBindTarget
+
+		c := newBindContext("POST", "http://localhost/", test.contentType, test.body)
+		if err := c.Bind(&v); err != nil {
+			t.Errorf("Unknown '%s' fields should be ignored by default, %s found", test.contentType, err)
+		}
+
+		c = newBindContext("POST", "http://localhost/", test.contentType, test.body)
+		c.yarf.StrictBinding = true
+		err := c.Bind(&v)
+		if yerr, ok := err.(YError); !ok || yerr.Code() != 400 {
+			t.Errorf("Unknown '%s' fields should be rejected on strict mode, %v found", test.contentType, err)
+		}
+	}
+}
+
+func TestBindQuery(t *testing.T) {
+	c := newBindContext("GET", "http://localhost/?id=5&tags=x&tags=y&Page=2&other=1", "", "")
+
+	var v MockBindTarget
+	if err := c.BindQuery(&v); err != nil {
+		t.Fatalf("BindQuery() returned an error: %s", err)
+	}
+	if v.ID != 5 || len(v.Tags) != 2 || v.Page != 2 {
+		t.Errorf("Query wasn't bound correctly: %+v", v)
+	}
+
+	c.yarf.StrictBinding = true
+	if err := c.BindQuery(&v); err == nil {
+		t.Error("BindQuery() should reject unknown query params on strict mode")
+	}
+
+	c = newBindContext("GET", "http://localhost/?id=text", "", "")
+	if yerr, ok := c.BindQuery(&v).(YError); !ok || yerr.Code() != 400 {
+		t.Error("BindQuery() should return a 400 error for invalid values")
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	c := newBindContext("GET", "http://localhost/", "", "")
+	c.Params.Set("id", "9")
+	c.Params.Set("Page", "3")
+
+	var v MockBindTarget
+	if err := c.BindParams(&v); err != nil {
+		t.Fatalf("BindParams() returned an error: %s", err)
+	}
+	if v.ID != 9 || v.Page != 3 {
+		t.Errorf("Params weren't bound correctly: %+v", v)
+	}
+
+	c.Params.Set("id", "text")
+	if yerr, ok := c.BindParams(&v).(YError); !ok || yerr.Code() != 400 {
+		t.Error("BindParams() should return a 400 error for invalid values")
+	}
+}
@@ -1,44 +1,208 @@
 package yarf
 
 import (
+	"container/list"
+	"hash/fnv"
 	"sync"
+	"sync/atomic"
 )
 
-// RouteCache stores previously matched and parsed routes
+// DefaultCacheSize is the maximum amount of routes stored by the default route cache.
+const DefaultCacheSize = 10000
+
+// RouteCache stores previously matched and parsed routes.
+// Cached routes are immutable and shared by all requests to the same path,
+// so their data is copied from and into each request Context.
+// They keep the routes version they were matched with, so the ones matched before routes were added are ignored.
 type RouteCache struct {
-	route  []Router
-	params Params
+	route   []Router
+	params  Params
+	tail    string
+	version uint64
+}
+
+// newRouteCache creates a RouteCache from the routes and params matched on a Context,
+// using the routes version read before the match.
+func newRouteCache(c *Context, version uint64) RouteCache {
+	return RouteCache{
+		route:   c.groupDispatch,
+		params:  append(Params(nil), c.Params...),
+		tail:    c.tail,
+		version: version,
+	}
 }
 
-// Cache is the service handler for route caching
-type Cache struct {
-	// Cache data storage
-	storage map[string]RouteCache
+// restore sets the cached routes and params into a Context.
+// Params are copied into the Context's own storage, so they can be changed safely.
+func (rc RouteCache) restore(c *Context) {
+	c.groupDispatch = rc.route
+	c.Params = append(c.Params[:0], rc.params...)
+	c.tail = rc.tail
+}
+
+// Cache is the interface used by Yarf to store matched routes by request path.
+// Implementations have to be safe for concurrent use.
+type Cache interface {
+	// Get retrieves a RouteCache object by key name.
+	Get(key string) (RouteCache, bool)
+
+	// Set stores a RouteCache object under a key name.
+	Set(key string, rc RouteCache)
+
+	// Clear removes all the cached routes.
+	Clear()
+}
+
+// CacheStats holds the usage counters of a LRUCache.
+type CacheStats struct {
+	Hits      uint64 // Get calls returning a cached route
+	Misses    uint64 // Get calls not finding a cached route
+	Evictions uint64 // Routes removed to make room for new ones
+	Size      int    // Routes currently stored
+}
+
+// LRUCache is the default Cache implementation.
+// It stores up to a maximum amount of routes, evicting the least recently used ones when full.
+// The storage can be split into shards, each one with its own lock, to reduce contention under concurrent load.
+type LRUCache struct {
+	// Usage counters, first to keep them 64-bit aligned
+	hits      uint64
+	misses    uint64
+	evictions uint64
+
+	shards []*lruShard
+}
+
+// lruShard is a size-bounded portion of a LRUCache.
+type lruShard struct {
+	size    int
+	items   map[string]*list.Element
+	recency *list.List // Most recently used items first
 
 	// Sync Mutex
-	sync.RWMutex
+	sync.Mutex
+}
+
+// lruItem is the value stored on each lruShard list element.
+type lruItem struct {
+	key string
+	rc  RouteCache
+}
+
+// NewCache creates and initializes the default Cache service object,
+// a LRUCache with DefaultCacheSize capacity.
+func NewCache() Cache {
+	return NewLRUCache(DefaultCacheSize, 1)
 }
 
-// NewCache creates and initializes a new Cache service object.
-func NewCache() *Cache {
-	return &Cache{
-		storage: make(map[string]RouteCache),
+// NewLRUCache creates a LRUCache storing up to size routes, split into the given amount of shards.
+// Each shard holds an equal part of the total size.
+func NewLRUCache(size, shards int) *LRUCache {
+	if shards < 1 {
+		shards = 1
 	}
+	if size < shards {
+		size = shards
+	}
+
+	c := &LRUCache{
+		shards: make([]*lruShard, shards),
+	}
+	for i := range c.shards {
+		c.shards[i] = &lruShard{
+			size:    size / shards,
+			items:   make(map[string]*list.Element),
+			recency: list.New(),
+		}
+	}
+
+	return c
 }
 
-// Get retrieves a routeCache object by key name.
-func (c *Cache) Get(k string) (rc RouteCache, ok bool) {
-	c.RLock()
-	defer c.RUnlock()
+// shard returns the shard storing a key.
+func (c *LRUCache) shard(k string) *lruShard {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(k))
+
+	return c.shards[h.Sum32()%uint32(len(c.shards))]
+}
+
+// Get retrieves a RouteCache object by key name and marks it as recently used.
+func (c *LRUCache) Get(k string) (rc RouteCache, ok bool) {
+	s := c.shard(k)
+
+	s.Lock()
+	e, ok := s.items[k]
+	if ok {
+		s.recency.MoveToFront(e)
+		rc = e.Value.(*lruItem).rc
+	}
+	s.Unlock()
+
+	if ok {
+		atomic.AddUint64(&c.hits, 1)
+	} else {
+		atomic.AddUint64(&c.misses, 1)
+	}
 
-	rc, ok = c.storage[k]
 	return
 }
 
-// Set stores a routeCache object under a key name.
-func (c *Cache) Set(k string, r RouteCache) {
-	c.Lock()
-	defer c.Unlock()
+// Set stores a RouteCache object under a key name.
+// If the shard is full, the least recently used route is evicted.
+func (c *LRUCache) Set(k string, rc RouteCache) {
+	s := c.shard(k)
+
+	s.Lock()
+	defer s.Unlock()
+
+	if e, ok := s.items[k]; ok {
+		e.Value.(*lruItem).rc = rc
+		s.recency.MoveToFront(e)
+		return
+	}
+
+	if s.recency.Len() >= s.size {
+		last := s.recency.Back()
+		s.recency.Remove(last)
+		delete(s.items, last.Value.(*lruItem).key)
+		atomic.AddUint64(&c.evictions, 1)
+	}
+
+	s.items[k] = s.recency.PushFront(&lruItem{key: k, rc: rc})
+}
+
+// Clear removes all the cached routes.
+func (c *LRUCache) Clear() {
+	for _, s := range c.shards {
+		s.Lock()
+		s.items = make(map[string]*list.Element)
+		s.recency.Init()
+		s.Unlock()
+	}
+}
+
+// Len returns the amount of routes currently stored.
+func (c *LRUCache) Len() (n int) {
+	for _, s := range c.shards {
+		s.Lock()
+		n += s.recency.Len()
+		s.Unlock()
+	}
+
+	return
+}
 
-	c.storage[k] = r
+// Stats returns the cache usage counters.
+func (c *LRUCache) Stats() CacheStats {
+	return CacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Size:      c.Len(),
+	}
 }
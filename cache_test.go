@@ -0,0 +1,167 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestLRUCacheEviction(t *testing.T) {
+	c := NewLRUCache(2, 1)
+
+	c.Set("/a", RouteCache{})
+	c.Set("/b", RouteCache{})
+
+	// Use /a so /b is the least recently used
+	if _, ok := c.Get("/a"); !ok {
+		t.Fatal("/a should be cached")
+	}
+
+	c.Set("/c", RouteCache{})
+
+	if _, ok := c.Get("/b"); ok {
+		t.Error("/b should be evicted as the least recently used route")
+	}
+	if _, ok := c.Get("/a"); !ok {
+		t.Error("/a should be kept as a recently used route")
+	}
+	if _, ok := c.Get("/c"); !ok {
+		t.Error("/c should be cached")
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 3 || stats.Misses != 1 || stats.Evictions != 1 || stats.Size != 2 {
+		t.Errorf("Stats should be 3 hits, 1 miss, 1 eviction and size 2, %+v found", stats)
+	}
+}
+
+func TestLRUCacheShards(t *testing.T) {
+	c := NewLRUCache(100, 4)
+
+	for i := 0; i < 1000; i++ {
+		c.Set("/users/"+strconv.Itoa(i), RouteCache{})
+	}
+
+	if c.Len() > 100 {
+		t.Errorf("Cache should store up to 100 routes, %d found", c.Len())
+	}
+	if c.Stats().Evictions != uint64(1000-c.Len()) {
+		t.Errorf("Evictions should be %d, %d found", 1000-c.Len(), c.Stats().Evictions)
+	}
+
+	c.Clear()
+
+	if c.Len() != 0 {
+		t.Errorf("Cache should be empty after Clear(), %d routes found", c.Len())
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	y := New()
+	y.Cache = NewLRUCache(10, 1)
+
+	g := RouteGroup("/users")
+	g.Add("/:id", new(MockResource))
+	y.AddGroup(g)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/users/new", nil)
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	if y.Cache.(*LRUCache).Len() != 1 {
+		t.Fatal("Matched route should be cached")
+	}
+
+	// Adding routes after the first request clears the cache.
+	y.Add("/other", new(MockResource))
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/other", nil)
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	if _, ok := y.Cache.Get("/users/new"); ok {
+		t.Error("Cache should be cleared after adding routes")
+	}
+}
+
+func TestCacheStaleRoute(t *testing.T) {
+	y := New()
+	y.Add("/users", Handlers{"GET": func(c *Context) error {
+		c.Render("users")
+		return nil
+	}})
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/users", nil)
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	// A request matched before routes were added stores its route after the cache was cleared.
+	stale, _ := y.Cache.Get("/users")
+	y.Add("/other", new(MockResource))
+	y.checkCache()
+	stale.route = []Router{Route("/users", new(MockResource))}
+	y.Cache.Set("/users", stale)
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Body.String() != "users" {
+		t.Errorf("Routes cached with an old routes version should be ignored, %d '%s' found", res.Code, res.Body.String())
+	}
+}
+
+func TestCachePerRouter(t *testing.T) {
+	y1 := New()
+	y1.Add("/users", new(MockResource))
+	y2 := New()
+	api := RouteGroup("/api")
+	y2.AddGroup(api)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/users", nil)
+	y1.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Routes added to another router don't clear the cache
+	y2.Add("/other", new(MockResource))
+	y1.ServeHTTP(httptest.NewRecorder(), req)
+	if _, ok := y1.Cache.Get("/users"); !ok {
+		t.Error("Adding routes to a router shouldn't clear the cache of other routers")
+	}
+
+	// Routes added to nested groups clear the cache of their router
+	req, _ = http.NewRequest("GET", "http://localhost:8080/api/nested", nil)
+	y2.ServeHTTP(httptest.NewRecorder(), req)
+	api.Add("/nested", Handlers{"GET": func(c *Context) error {
+		c.Render("nested")
+		return nil
+	}})
+	res := httptest.NewRecorder()
+	y2.ServeHTTP(res, req)
+	if res.Body.String() != "nested" {
+		t.Errorf("Routes added to nested groups should be matched, %d found", res.Code)
+	}
+}
+
+func TestCacheAddWhileServing(t *testing.T) {
+	y := New()
+	y.Add("/users/:id", new(MockResource))
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			y.Add("/route"+strconv.Itoa(i), new(MockResource))
+		}
+		close(done)
+	}()
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/users/1", nil)
+	for i := 0; i < 100; i++ {
+		y.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	<-done
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/route99", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 405 {
+		t.Errorf("Routes added while serving should match, %d found", res.Code)
+	}
+}
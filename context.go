@@ -4,7 +4,9 @@ import (
 	"compress/gzip"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -24,30 +26,62 @@ type ContextData interface {
 	Del(key string) error
 }
 
-// Params wraps a map[string]string and adds Get/Set/Del methods to work with it.
-// Inspired on url.Values but simpler as it doesn't handles a map[string][]string
-type Params map[string]string
+// Param is a single route param.
+type Param struct {
+	Key   string
+	Value string
+
+	typed interface{} // Value converted by the param type constraint, if any
+}
+
+// Params stores the route params as a list of key/value pairs and adds Get/Set/Del methods to work with it.
+// Inspired on url.Values but simpler as it handles a single value for each key.
+// Each Context owns its Params storage, so changes made by a request are never seen by others.
+// Since version 0.9.0 it's a slice instead of a map[string]string: use the Get/Set/Del methods to access the params.
+type Params []Param
 
 // Get gets the first value associated with the given key.
 // If there are no values associated with the key, Get returns
 // the empty string.
 func (p Params) Get(key string) string {
-	if p == nil {
-		return ""
+	if i := p.index(key); i >= 0 {
+		return p[i].Value
 	}
 
-	param, _ := p[key]
-	return param
+	return ""
 }
 
 // Set sets the key to value. It replaces any existing values.
-func (p Params) Set(key, value string) {
-	p[key] = value
+func (p *Params) Set(key, value string) {
+	p.set(key, value, nil)
 }
 
 // Del deletes the values associated with key.
-func (p Params) Del(key string) {
-	delete(p, key)
+func (p *Params) Del(key string) {
+	if i := p.index(key); i >= 0 {
+		*p = append((*p)[:i], (*p)[i+1:]...)
+	}
+}
+
+// index returns the position of the key, or -1 if it isn't present.
+func (p Params) index(key string) int {
+	for i := range p {
+		if p[i].Key == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// set sets the key to value, along with its typed value.
+func (p *Params) set(key, value string, typed interface{}) {
+	if i := p.index(key); i >= 0 {
+		(*p)[i] = Param{key, value, typed}
+		return
+	}
+
+	*p = append(*p, Param{key, value, typed})
 }
 
 // Context is the data/status storage of every YARF request.
@@ -57,29 +91,73 @@ type Context struct {
 	// The *http.Request object as received by the HandleFunc.
 	Request *http.Request
 
-	// The http.ResponseWriter object as received by the HandleFunc.
+	// The http.ResponseWriter object as received by the HandleFunc, wrapped by a *ResponseWriter.
 	Response http.ResponseWriter
 
+	// Response wrapper tracking the response status
+	writer *ResponseWriter
+
 	// Parameters received through URL route
 	Params Params
 
+	// Params storage, so most requests don't need to allocate it
+	params [8]Param
+
 	// Free storage to be used freely by apps to their convenience.
 	Data ContextData
 
 	// Group route storage for dispatch
 	groupDispatch []Router
+
+	// Routers matching the request, from the route to the outermost group.
+	// Unlike groupDispatch, it isn't consumed by the dispatch.
+	chain []Router
+
+	// Yarf server handling the request
+	yarf *Yarf
+
+	// A route matched the request
+	matched bool
+
+	// The error response was already written inside net/http middleware wrappers
+	errorHandled bool
+
+	// The matched route depends on more than the request path, so it can't be cached
+	uncacheable bool
+
+	// Request path matched by the route catch-all wildcard
+	tail string
 }
 
 // NewContext creates a new *Context object with default values and returns it.
+// The http.ResponseWriter is wrapped by a *ResponseWriter, unless it already is one.
 func NewContext(r *http.Request, rw http.ResponseWriter) *Context {
-	return &Context{
-		Request:  r,
-		Response: rw,
-		Params:   Params{},
+	c := &Context{
+		Request: r,
 	}
+	c.Params = c.params[:0]
+
+	if rw != nil {
+		w, ok := rw.(*ResponseWriter)
+		if !ok {
+			w = NewResponseWriter(rw)
+		}
+		c.writer = w
+		c.Response = w
+	}
+
+	return c
+}
+
+// Writer returns the *ResponseWriter wrapping the request response,
+// to check the status code and the amount of bytes written, or if the response was already sent.
+// It keeps tracking the response even when net/http middleware replaces Context.Response.
+func (c *Context) Writer() *ResponseWriter {
+	return c.writer
 }
 
 // Status sets the HTTP status code to be returned on the response.
+// It's sent along the response body, so headers can still be set after it.
 func (c *Context) Status(code int) {
 	c.Response.WriteHeader(code)
 }
@@ -89,6 +167,63 @@ func (c *Context) Param(name string) string {
 	return c.Params.Get(name)
 }
 
+// ParamValue returns the typed value of a route param defined with a type constraint, as in /:id<int>.
+// For params without type constraints it returns the string value.
+// It returns nil if the param doesn't exist.
+func (c *Context) ParamValue(name string) interface{} {
+	i := c.Params.index(name)
+	if i < 0 {
+		return nil
+	}
+	if c.Params[i].typed != nil {
+		return c.Params[i].typed
+	}
+
+	return c.Params[i].Value
+}
+
+// ParamInt returns the int value of a route param.
+// Params defined as :name<int> are already parsed by the router,
+// other params are converted from their string value.
+// It returns 0 if the param doesn't exist or isn't an integer.
+func (c *Context) ParamInt(name string) int {
+	if i, ok := c.ParamValue(name).(int); ok {
+		return i
+	}
+
+	i, _ := strconv.Atoi(c.Params.Get(name))
+	return i
+}
+
+// ParamFloat returns the float64 value of a route param.
+// Params defined as :name<float> are already parsed by the router,
+// other params are converted from their string value.
+// It returns 0 if the param doesn't exist or isn't a number.
+func (c *Context) ParamFloat(name string) float64 {
+	if f, ok := c.ParamValue(name).(float64); ok {
+		return f
+	}
+
+	f, _ := strconv.ParseFloat(c.Params.Get(name), 64)
+	return f
+}
+
+// Matched returns true if a route matched the request.
+// It's useful for global middleware, that runs for every request.
+func (c *Context) Matched() bool {
+	return c.matched
+}
+
+// URLFor builds the path to a named route of the Yarf server handling the request.
+// It works exactly as Yarf.URL()
+func (c *Context) URLFor(name string, params ...string) (string, error) {
+	if c.yarf == nil {
+		return "", errors.New("yarf: URLFor needs a Context created by a Yarf server")
+	}
+
+	return c.yarf.URL(name, params...)
+}
+
 // FormValue is a wrapper for c.Request.Form.Get() and it calls c.Request.ParseForm().
 func (c *Context) FormValue(name string) string {
 	c.Request.ParseForm()
@@ -147,99 +282,117 @@ func (c *Context) Redirect(url string, code int) {
 // Render writes a string to the http.ResponseWriter.
 // This is the default renderer that just sends the string to the client.
 // Check other Render[Type] functions for different types.
-func (c *Context) Render(content string) {
+func (c *Context) Render(content string) error {
 	// Write response
-	c.Response.Write([]byte(content))
+	_, err := c.Response.Write([]byte(content))
+	return err
+}
+
+// setContentType sets the Content-Type header, unless it was already set.
+func (c *Context) setContentType(contentType string) {
+	if c.Response.Header().Get("Content-Type") == "" {
+		c.Response.Header().Set("Content-Type", contentType)
+	}
 }
 
 // RenderGzip takes a []byte content and if the client accepts compressed responses,
 // writes the compressed version of the content to the response.
 // Otherwise it just writes the plain []byte to it.
+// The Content-Type is detected from the content, unless it was already set.
 func (c *Context) RenderGzip(content []byte) error {
+	// Detect content type
+	c.setContentType(http.DetectContentType(content))
+
 	// Check if client accepts compression
 	if !strings.Contains(c.Request.Header.Get("Accept-Encoding"), "gzip") {
-		c.Response.Write(content)
-		return nil
+		_, err := c.Response.Write(content)
+		return err
 	}
 
-	// Detect content type
-	c.Response.Header().Set("Content-Type", http.DetectContentType(content))
-
-	// Write compressed content
-	gz := gzip.NewWriter(c.Response)
-	defer gz.Close()
-
 	c.Response.Header().Set("Content-Encoding", "gzip")
-	c.Response.Header().Set("Vary", "Accept-Encoding")
+	c.Response.Header().Add("Vary", "Accept-Encoding")
 	c.Response.Header().Del("Content-Length")
 
-	gz.Write(content)
+	// Write compressed content
+	gz := gzip.NewWriter(c.Response)
+	if _, err := gz.Write(content); err != nil {
+		gz.Close()
+		return err
+	}
 
-	return nil
+	return gz.Close()
 }
 
 // RenderJSON takes a interface{} object and writes the JSON encoded string of it.
-func (c *Context) RenderJSON(data interface{}) {
-	// Set content
+// Encoding errors are returned as *RenderError, without writing anything to the response.
+func (c *Context) RenderJSON(data interface{}) error {
 	encoded, err := json.Marshal(data)
 	if err != nil {
-		c.Response.Write([]byte(err.Error()))
-	} else {
-		c.Response.Write(encoded)
+		return &RenderError{Format: "JSON", Err: err}
 	}
+
+	c.setContentType("application/json; charset=utf-8")
+	return c.write(encoded)
 }
 
 // RenderJSONIndent is the indented (beauty) of RenderJSON
-func (c *Context) RenderJSONIndent(data interface{}) {
-	// Set content
+func (c *Context) RenderJSONIndent(data interface{}) error {
 	encoded, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
-		c.Response.Write([]byte(err.Error()))
-	} else {
-		c.Response.Write(encoded)
+		return &RenderError{Format: "JSON", Err: err}
 	}
+
+	c.setContentType("application/json; charset=utf-8")
+	return c.write(encoded)
 }
 
 // RenderGzipJSON takes a interface{} object and writes the JSON verion through RenderGzip.
-func (c *Context) RenderGzipJSON(data interface{}) {
-	// Create JSON content
+func (c *Context) RenderGzipJSON(data interface{}) error {
 	encoded, err := json.Marshal(data)
 	if err != nil {
-		c.Response.Write([]byte(err.Error()))
+		return &RenderError{Format: "JSON", Err: err}
 	}
 
-	c.RenderGzip(encoded)
+	c.setContentType("application/json; charset=utf-8")
+	return c.RenderGzip(encoded)
 }
 
 // RenderXML takes a interface{} object and writes the XML encoded string of it.
-func (c *Context) RenderXML(data interface{}) {
-	// Set content
+// Encoding errors are returned as *RenderError, without writing anything to the response.
+func (c *Context) RenderXML(data interface{}) error {
 	encoded, err := xml.Marshal(data)
 	if err != nil {
-		c.Response.Write([]byte(err.Error()))
-	} else {
-		c.Response.Write(encoded)
+		return &RenderError{Format: "XML", Err: err}
 	}
+
+	c.setContentType("application/xml; charset=utf-8")
+	return c.write(encoded)
 }
 
 // RenderXMLIndent is the indented (beauty) of RenderXML
-func (c *Context) RenderXMLIndent(data interface{}) {
-	// Set content
+func (c *Context) RenderXMLIndent(data interface{}) error {
 	encoded, err := xml.MarshalIndent(data, "", "  ")
 	if err != nil {
-		c.Response.Write([]byte(err.Error()))
-	} else {
-		c.Response.Write(encoded)
+		return &RenderError{Format: "XML", Err: err}
 	}
+
+	c.setContentType("application/xml; charset=utf-8")
+	return c.write(encoded)
 }
 
 // RenderGzipXML takes a interface{} object and writes the XML verion through RenderGzip.
-func (c *Context) RenderGzipXML(data interface{}) {
-	// Set XML content
+func (c *Context) RenderGzipXML(data interface{}) error {
 	encoded, err := xml.Marshal(data)
 	if err != nil {
-		c.Response.Write([]byte(err.Error()))
+		return &RenderError{Format: "XML", Err: err}
 	}
 
-	c.RenderGzip(encoded)
+	c.setContentType("application/xml; charset=utf-8")
+	return c.RenderGzip(encoded)
+}
+
+// write sends encoded content to the response.
+func (c *Context) write(content []byte) error {
+	_, err := c.Response.Write(content)
+	return err
 }
@@ -1,8 +1,11 @@
 package yarf
 
 import (
+	"compress/gzip"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -31,9 +34,23 @@ func TestNewContext(t *testing.T) {
 	if c.Request != req {
 		t.Error("Request object provided to NewContext() wasn't set correctly on Context object")
 	}
-	if c.Response != res {
+	if c.Writer() == nil || c.Writer().Unwrap() != res {
 		t.Error("Response object provided to NewContext() wasn't set correctly on Context object")
 	}
+	if c.Response != c.Writer() {
+		t.Error("Context.Response should be the ResponseWriter wrapper")
+	}
+}
+
+func TestNewContextReusesResponseWriter(t *testing.T) {
+	req, res := createRequestResponse()
+
+	w := NewResponseWriter(res)
+	c := NewContext(req, w)
+
+	if c.Writer() != w {
+		t.Error("NewContext() should reuse a *ResponseWriter instead of wrapping it again")
+	}
 }
 
 func TestStatus(t *testing.T) {
@@ -41,9 +58,31 @@ func TestStatus(t *testing.T) {
 
 	c := NewContext(req, res)
 	c.Status(201)
+	c.Render("created")
 
-	if c.Response.(*httptest.ResponseRecorder).Code != 201 {
-		t.Errorf("Status %d set to Status() method, %d found", 201, c.Response.(*httptest.ResponseRecorder).Code)
+	if res.Code != 201 {
+		t.Errorf("Status %d set to Status() method, %d found", 201, res.Code)
+	}
+}
+
+func TestStatusBeforeRender(t *testing.T) {
+	y := New()
+	y.Add("/users", Handlers{"POST": func(c *Context) error {
+		c.Status(201)
+		return c.RenderJSON(map[string]int{"id": 1})
+	}})
+
+	srv := httptest.NewServer(y)
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/users", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != 201 || res.Header.Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Errorf("Status set before rendering should keep the render Content-Type, %d '%s' found", res.StatusCode, res.Header.Get("Content-Type"))
 	}
 }
 
@@ -58,6 +97,24 @@ func TestParam(t *testing.T) {
 	}
 }
 
+func TestParams(t *testing.T) {
+	var p Params
+
+	p.Set("a", "1")
+	p.Set("b", "2")
+	p.Set("a", "3")
+
+	if len(p) != 2 || p.Get("a") != "3" || p.Get("b") != "2" {
+		t.Errorf("Params should contain a=3 and b=2, %v found", p)
+	}
+
+	p.Del("a")
+
+	if len(p) != 1 || p.Get("a") != "" || p.Get("b") != "2" {
+		t.Errorf("Params should contain b=2 only, %v found", p)
+	}
+}
+
 func TestGetClientIP(t *testing.T) {
 	req, res := createRequestResponse()
 
@@ -74,8 +131,8 @@ func TestRender(t *testing.T) {
 	c := NewContext(req, res)
 	c.Render("TEST")
 
-	if c.Response.(*httptest.ResponseRecorder).Body.String() != "TEST" {
-		t.Errorf("'%s' sent to Render() method, '%s' found on Response object", "TEST", c.Response.(*httptest.ResponseRecorder).Body.String())
+	if res.Body.String() != "TEST" {
+		t.Errorf("'%s' sent to Render() method, '%s' found on Response object", "TEST", res.Body.String())
 	}
 }
 
@@ -85,8 +142,8 @@ func TestRenderJSON(t *testing.T) {
 	c := NewContext(req, res)
 	c.RenderJSON("TEST")
 
-	if c.Response.(*httptest.ResponseRecorder).Body.String() != "\"TEST\"" {
-		t.Errorf("'%s' sent to RenderJSON() method, '%s' found on Response object", "TEST", c.Response.(*httptest.ResponseRecorder).Body.String())
+	if res.Body.String() != "\"TEST\"" {
+		t.Errorf("'%s' sent to RenderJSON() method, '%s' found on Response object", "TEST", res.Body.String())
 	}
 }
 
@@ -96,8 +153,8 @@ func TestRenderJSONIndent(t *testing.T) {
 	c := NewContext(req, res)
 	c.RenderJSONIndent("TEST")
 
-	if c.Response.(*httptest.ResponseRecorder).Body.String() != "\"TEST\"" {
-		t.Errorf("'%s' sent to RenderJSONIndent() method, '%s' found on Response object", "TEST", c.Response.(*httptest.ResponseRecorder).Body.String())
+	if res.Body.String() != "\"TEST\"" {
+		t.Errorf("'%s' sent to RenderJSONIndent() method, '%s' found on Response object", "TEST", res.Body.String())
 	}
 }
 
@@ -107,8 +164,8 @@ func TestRenderXML(t *testing.T) {
 	c := NewContext(req, res)
 	c.RenderXML("TEST")
 
-	if c.Response.(*httptest.ResponseRecorder).Body.String() != "<string>TEST</string>" {
-		t.Errorf("'%s' sent to RenderXML() method, '%s' found on Response object", "TEST", c.Response.(*httptest.ResponseRecorder).Body.String())
+	if res.Body.String() != "<string>TEST</string>" {
+		t.Errorf("'%s' sent to RenderXML() method, '%s' found on Response object", "TEST", res.Body.String())
 	}
 }
 
@@ -118,7 +175,119 @@ func TestRenderXMLIndent(t *testing.T) {
 	c := NewContext(req, res)
 	c.RenderXMLIndent("TEST")
 
-	if c.Response.(*httptest.ResponseRecorder).Body.String() != "<string>TEST</string>" {
-		t.Errorf("'%s' sent to RenderXMLIndent() method, '%s' found on Response object", "TEST", c.Response.(*httptest.ResponseRecorder).Body.String())
+	if res.Body.String() != "<string>TEST</string>" {
+		t.Errorf("'%s' sent to RenderXMLIndent() method, '%s' found on Response object", "TEST", res.Body.String())
+	}
+}
+
+func TestRenderContentType(t *testing.T) {
+	tests := []struct {
+		render      func(c *Context) error
+		contentType string
+	}{
+		{func(c *Context) error { return c.RenderJSON("TEST") }, "application/json; charset=utf-8"},
+		{func(c *Context) error { return c.RenderJSONIndent("TEST") }, "application/json; charset=utf-8"},
+		{func(c *Context) error { return c.RenderGzipJSON("TEST") }, "application/json; charset=utf-8"},
+		{func(c *Context) error { return c.RenderXML("TEST") }, "application/xml; charset=utf-8"},
+		{func(c *Context) error { return c.RenderXMLIndent("TEST") }, "application/xml; charset=utf-8"},
+		{func(c *Context) error { return c.RenderGzipXML("TEST") }, "application/xml; charset=utf-8"},
+		{func(c *Context) error { return c.RenderGzip([]byte("TEST")) }, "text/plain; charset=utf-8"},
+	}
+
+	for i, test := range tests {
+		req, res := createRequestResponse()
+		c := NewContext(req, res)
+		c.Status(201)
+
+		if err := test.render(c); err != nil {
+			t.Errorf("Render %d returned an error: %s", i, err)
+		}
+		if res.Code != 201 || res.Result().Header.Get("Content-Type") != test.contentType {
+			t.Errorf("Render %d should send 201 with Content-Type '%s', %d '%s' found", i, test.contentType, res.Code, res.Result().Header.Get("Content-Type"))
+		}
+	}
+
+	// Content-Type set before rendering is kept
+	req, res := createRequestResponse()
+	c := NewContext(req, res)
+	c.Response.Header().Set("Content-Type", "application/vnd.api+json")
+	c.RenderJSON("TEST")
+
+	if res.Result().Header.Get("Content-Type") != "application/vnd.api+json" {
+		t.Errorf("Render functions shouldn't replace the Content-Type already set, '%s' found", res.Result().Header.Get("Content-Type"))
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	data := map[string]interface{}{"invalid": make(chan int)}
+
+	renders := []func(c *Context) error{
+		func(c *Context) error { return c.RenderJSON(data) },
+		func(c *Context) error { return c.RenderJSONIndent(data) },
+		func(c *Context) error { return c.RenderGzipJSON(data) },
+		func(c *Context) error { return c.RenderXML(data) },
+		func(c *Context) error { return c.RenderXMLIndent(data) },
+		func(c *Context) error { return c.RenderGzipXML(data) },
+	}
+
+	for i, render := range renders {
+		req, res := createRequestResponse()
+		req.Header.Set("Accept-Encoding", "gzip")
+		c := NewContext(req, res)
+
+		err := render(c)
+		if _, ok := err.(*RenderError); !ok {
+			t.Errorf("Render %d should return a *RenderError, %v found", i, err)
+		}
+		if res.Body.Len() > 0 || c.Writer().Written() {
+			t.Errorf("Render %d shouldn't write anything on errors, '%s' found", i, res.Body.String())
+		}
+	}
+}
+
+func TestRenderGzip(t *testing.T) {
+	req, res := createRequestResponse()
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	c := NewContext(req, res)
+	if err := c.RenderGzipJSON("TEST"); err != nil {
+		t.Fatalf("RenderGzipJSON() returned an error: %s", err)
+	}
+
+	if res.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Content-Encoding should be 'gzip', '%s' found", res.Header().Get("Content-Encoding"))
+	}
+
+	gz, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatalf("Response should be gzip compressed: %s", err)
+	}
+	body, _ := io.ReadAll(gz)
+	if string(body) != "\"TEST\"" {
+		t.Errorf("Uncompressed body should be '\"TEST\"', '%s' found", body)
+	}
+}
+
+type MockRenderErrorResource struct {
+	Resource
+}
+
+func (r *MockRenderErrorResource) Get(c *Context) error {
+	return c.RenderJSON(make(chan int))
+}
+
+func TestRenderErrorResponse(t *testing.T) {
+	y := New()
+	y.Add("/render", new(MockRenderErrorResource))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/render", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 500 {
+		t.Errorf("Render errors should return 500, %d found", res.Code)
+	}
+	if strings.Contains(res.Body.String(), "chan") {
+		t.Errorf("Render error details shouldn't be sent to the client, '%s' found", res.Body.String())
 	}
 }
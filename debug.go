@@ -0,0 +1,169 @@
+package yarf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// DebugInfo holds the error details rendered by the developer error page when Yarf.Debug is enabled.
+type DebugInfo struct {
+	Status     int               `json:"status"`               // HTTP status code
+	ID         int               `json:"id"`                   // YError ID
+	Msg        string            `json:"msg"`                  // YError message
+	Body       string            `json:"body,omitempty"`       // YError body, as sent on production mode
+	Causes     []string          `json:"causes,omitempty"`     // Wrapped errors chain, from the returned error to the root cause
+	Method     string            `json:"method"`               // Request method
+	URL        string            `json:"url"`                  // Request URL
+	Route      string            `json:"route,omitempty"`      // Matched route path, including group prefixes
+	Resource   string            `json:"resource,omitempty"`   // Matched resource type
+	Params     map[string]string `json:"params,omitempty"`     // Route params
+	Middleware []string          `json:"middleware,omitempty"` // Middleware types that ran for the request, in dispatch order
+	Stack      string            `json:"stack,omitempty"`      // Stack trace, for panics
+}
+
+// stackTracer is implemented by errors carrying the stack trace where they happened.
+type stackTracer interface {
+	Stack() []byte
+}
+
+// newDebugInfo collects the debug details of an error.
+func (y *Yarf) newDebugInfo(c *Context, err error) *DebugInfo {
+	yerr := AsYError(err)
+
+	info := &DebugInfo{
+		Status: yerr.Code(),
+		ID:     yerr.ID(),
+		Msg:    yerr.Msg(),
+		Body:   yerr.Body(),
+		Method: c.Request.Method,
+		URL:    c.Request.URL.String(),
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		info.Causes = append(info.Causes, fmt.Sprintf("%T: %s", e, e.Error()))
+	}
+
+	var st stackTracer
+	if errors.As(err, &st) {
+		info.Stack = string(st.Stack())
+	}
+
+	if len(c.Params) > 0 {
+		info.Params = make(map[string]string, len(c.Params))
+		for _, p := range c.Params {
+			info.Params[p.Key] = p.Value
+		}
+	}
+
+	for _, m := range y.middleware {
+		info.Middleware = append(info.Middleware, fmt.Sprintf("%T", m))
+	}
+
+	if len(c.chain) == 0 {
+		return info
+	}
+
+	// Groups, from the outer to the inner one
+	var parts []string
+	var groups []*GroupRoute
+	if g, ok := y.GroupRouter.(*GroupRoute); ok {
+		groups = append(groups, g)
+	}
+	for i := len(c.chain) - 1; i > 0; i-- {
+		if g, ok := c.chain[i].(*GroupRoute); ok {
+			groups = append(groups, g)
+		}
+	}
+	for _, g := range groups {
+		parts = append(parts, g.routeParts...)
+		for _, m := range g.middleware {
+			info.Middleware = append(info.Middleware, fmt.Sprintf("%T", m))
+		}
+	}
+
+	if r, ok := c.chain[0].(*route); ok {
+		info.Route = "/" + strings.Join(append(parts, r.routeParts...), "/")
+
+		var resource interface{} = r.handler
+		if r.mount != nil {
+			resource = r.mount
+		}
+		info.Resource = fmt.Sprintf("%T", resource)
+
+		for _, m := range r.middleware {
+			info.Middleware = append(info.Middleware, fmt.Sprintf("%T", m))
+		}
+	}
+
+	return info
+}
+
+// debugTypes are the formats of the developer error page, in priority order.
+var debugTypes = []string{"application/json", "text/html"}
+
+// renderDebug writes the developer error page, as HTML for browsers or JSON otherwise.
+func (y *Yarf) renderDebug(c *Context, err error) error {
+	info := y.newDebugInfo(c, err)
+
+	var body []byte
+	contentType := "application/json; charset=utf-8"
+
+	if negotiate(c.Request.Header.Get("Accept"), debugTypes) == 1 {
+		contentType = "text/html; charset=utf-8"
+
+		buf := new(bytes.Buffer)
+		if err := debugTemplate.Execute(buf, info); err != nil {
+			return &RenderError{Format: "HTML", Err: err}
+		}
+		body = buf.Bytes()
+	} else {
+		encoded, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return &RenderError{Format: "JSON", Err: err}
+		}
+		body = encoded
+	}
+
+	c.Response.Header().Set("Content-Type", contentType)
+	c.Response.Header().Add("Vary", "Accept")
+	c.Response.WriteHeader(info.Status)
+
+	return c.write(body)
+}
+
+// debugTemplate is the HTML developer error page.
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} {{.Msg}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #333; }
+h1 { color: #c00; }
+th { text-align: left; padding-right: 2em; vertical-align: top; }
+pre { background: #f4f4f4; padding: 1em; overflow: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Status}} {{.Msg}}</h1>
+<table>
+<tr><th>Request</th><td>{{.Method}} {{.URL}}</td></tr>
+<tr><th>Error ID</th><td>{{.ID}}</td></tr>
+{{if .Body}}<tr><th>Body</th><td>{{.Body}}</td></tr>{{end}}
+{{if .Route}}<tr><th>Route</th><td>{{.Route}}</td></tr>
+<tr><th>Resource</th><td>{{.Resource}}</td></tr>{{end}}
+{{range $k, $v := .Params}}<tr><th>Param {{$k}}</th><td>{{$v}}</td></tr>
+{{end}}</table>
+{{if .Causes}}<h2>Errors chain</h2>
+<ol>{{range .Causes}}<li>{{.}}</li>{{end}}</ol>{{end}}
+{{if .Middleware}}<h2>Middleware</h2>
+<ol>{{range .Middleware}}<li>{{.}}</li>{{end}}</ol>{{end}}
+{{if .Stack}}<h2>Stack trace</h2>
+<pre>{{.Stack}}</pre>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,97 @@
+package yarf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type MockStackError struct {
+	error
+}
+
+func (e MockStackError) Stack() []byte {
+	return []byte("goroutine 1 [running]:\nmain.main()")
+}
+
+func debugServer() *Yarf {
+	g := RouteGroup("/api")
+	g.Insert(new(MockMiddleware))
+	g.Add("/users/:id", &MockErrorResource{err: fmt.Errorf("loading user: %w", ErrorNotFound())}, Use(new(MockHeaderMiddleware)))
+	g.Add("/panic", &MockErrorResource{err: MockStackError{fmt.Errorf("<script>")}})
+
+	y := New()
+	y.Debug = true
+	y.InsertGlobal(&MockMatchedMiddleware{log: new([]string)})
+	y.AddGroup(g)
+
+	return y
+}
+
+func TestDebugJSON(t *testing.T) {
+	y := debugServer()
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/api/users/42", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 404 {
+		t.Errorf("Debug page should keep the error status code, %d found", res.Code)
+	}
+	if !strings.HasPrefix(res.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Debug page should be JSON by default, '%s' found", res.Header().Get("Content-Type"))
+	}
+
+	var info DebugInfo
+	if err := json.Unmarshal(res.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Invalid debug JSON: %s", err)
+	}
+
+	if info.Route != "/api/users/:id" || info.Resource != "*yarf.MockErrorResource" || info.Params["id"] != "42" {
+		t.Errorf("Debug info should include the matched route: %+v", info)
+	}
+	if len(info.Causes) != 2 || !strings.HasPrefix(info.Causes[1], "*yarf.NotFoundError") {
+		t.Errorf("Debug info should include the errors chain, %v found", info.Causes)
+	}
+	if strings.Join(info.Middleware, " ") != "*yarf.MockMatchedMiddleware *yarf.MockMiddleware *yarf.MockHeaderMiddleware" {
+		t.Errorf("Debug info should include the middleware chain, %v found", info.Middleware)
+	}
+	if info.Stack != "" {
+		t.Errorf("Debug info shouldn't include a stack for regular errors, '%s' found", info.Stack)
+	}
+}
+
+func TestDebugHTML(t *testing.T) {
+	y := debugServer()
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/api/panic", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if !strings.HasPrefix(res.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("Debug page should be HTML for browsers, '%s' found", res.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(res.Body.String(), "goroutine 1 [running]") {
+		t.Error("Debug page should include the stack trace")
+	}
+	if strings.Contains(res.Body.String(), "<script>") {
+		t.Error("Debug page should escape the error details")
+	}
+}
+
+func TestDebugDisabled(t *testing.T) {
+	y := debugServer()
+	y.Debug = false
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/api/panic", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 500 || res.Body.String() != "" {
+		t.Errorf("Production mode shouldn't send the details of errors without body, %d '%s' found", res.Code, res.Body.String())
+	}
+}
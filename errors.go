@@ -1,6 +1,8 @@
 package yarf
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 )
 
@@ -19,13 +21,31 @@ type CustomError struct {
 	ErrorCode int    // Internal YARF error code for further reference.
 	ErrorMsg  string // YARF error message.
 	ErrorBody string // Error content to be rendered to the client response.
+	Err       error  // Cause of the error, if any.
 }
 
-// Implements the error interface returning the ErrorMsg value of each error.
+// Implements the error interface returning the ErrorMsg value of each error,
+// followed by the cause message, if any.
 func (e *CustomError) Error() string {
+	if e.Err != nil {
+		return e.ErrorMsg + ": " + e.Err.Error()
+	}
+
 	return e.ErrorMsg
 }
 
+// Unwrap returns the cause of the error, so errors.Is and errors.As can inspect it.
+func (e *CustomError) Unwrap() error {
+	return e.Err
+}
+
+// wrap sets the optional cause received by the error constructors.
+func (e *CustomError) wrap(cause []error) {
+	if len(cause) > 0 {
+		e.Err = cause[0]
+	}
+}
+
 // Code returns the error's HTTP code to be used in the response.
 func (e *CustomError) Code() int {
 	return e.HTTPCode
@@ -46,17 +66,65 @@ func (e *CustomError) Body() string {
 	return e.ErrorBody
 }
 
+// AsYError returns the YError to be sent as response for any error.
+// It looks for the first YError in the error chain, so wrapped errors keep their status code.
+// Render errors are converted to UnexpectedError, and other errors to a 500 CustomError
+// without body, so their details are only available to the logger and the debug mode.
+// It returns nil for nil errors.
+func AsYError(err error) YError {
+	if err == nil {
+		return nil
+	}
+
+	var yerr YError
+	if errors.As(err, &yerr) {
+		return yerr
+	}
+
+	var rerr *RenderError
+	if errors.As(err, &rerr) {
+		// Encoding details aren't sent to the client
+		return ErrorUnexpected(err)
+	}
+
+	// Create default 500 error
+	return &CustomError{
+		HTTPCode:  500,
+		ErrorCode: 0,
+		ErrorMsg:  err.Error(),
+		Err:       err,
+	}
+}
+
+// RenderError is returned by the Render functions when the response data can't be encoded.
+// It's sent as an UnexpectedError response, so encoding details aren't exposed to the client.
+type RenderError struct {
+	Format string // Encoding format: JSON, XML
+	Err    error  // Encoding error
+}
+
+// Error returns the encoding error message.
+func (e *RenderError) Error() string {
+	return "yarf: " + e.Format + " render error: " + e.Err.Error()
+}
+
+// Unwrap returns the encoding error.
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
 // UnexpectedError is used when the origin of the error can't be discovered
 type UnexpectedError struct {
 	CustomError
 }
 
 // ErrorUnexpected creates UnexpectedError
-func ErrorUnexpected() *UnexpectedError {
+func ErrorUnexpected(cause ...error) *UnexpectedError {
 	e := new(UnexpectedError)
 	e.HTTPCode = http.StatusInternalServerError
 	e.ErrorCode = 0
 	e.ErrorMsg = "Unexpected error"
+	e.wrap(cause)
 
 	return e
 }
@@ -67,11 +135,12 @@ type MethodNotImplementedError struct {
 }
 
 // ErrorMethodNotImplemented creates MethodNotImplementedError
-func ErrorMethodNotImplemented() *MethodNotImplementedError {
+func ErrorMethodNotImplemented(cause ...error) *MethodNotImplementedError {
 	e := new(MethodNotImplementedError)
 	e.HTTPCode = http.StatusMethodNotAllowed
 	e.ErrorCode = 1
 	e.ErrorMsg = "Method not implemented"
+	e.wrap(cause)
 
 	return e
 }
@@ -82,11 +151,633 @@ type NotFoundError struct {
 }
 
 // ErrorNotFound creates NotFoundError
-func ErrorNotFound() *NotFoundError {
+func ErrorNotFound(cause ...error) *NotFoundError {
 	e := new(NotFoundError)
 	e.HTTPCode = http.StatusNotFound
 	e.ErrorCode = 2
 	e.ErrorMsg = "Not found"
+	e.wrap(cause)
+
+	return e
+}
+
+// BadRequestError is the HTTP 400 error equivalent.
+type BadRequestError struct {
+	CustomError
+}
+
+// ErrorBadRequest creates BadRequestError
+func ErrorBadRequest(cause ...error) *BadRequestError {
+	e := new(BadRequestError)
+	e.HTTPCode = http.StatusBadRequest
+	e.ErrorCode = 3
+	e.ErrorMsg = "Bad request"
+	e.wrap(cause)
+
+	return e
+}
+
+// UnsupportedMediaTypeError is the HTTP 415 error equivalent.
+type UnsupportedMediaTypeError struct {
+	CustomError
+}
+
+// ErrorUnsupportedMediaType creates UnsupportedMediaTypeError
+func ErrorUnsupportedMediaType(cause ...error) *UnsupportedMediaTypeError {
+	e := new(UnsupportedMediaTypeError)
+	e.HTTPCode = http.StatusUnsupportedMediaType
+	e.ErrorCode = 4
+	e.ErrorMsg = "Unsupported media type"
+	e.wrap(cause)
+
+	return e
+}
+
+// NotAcceptableError is the HTTP 406 error equivalent.
+type NotAcceptableError struct {
+	CustomError
+}
+
+// ErrorNotAcceptable creates NotAcceptableError
+func ErrorNotAcceptable(cause ...error) *NotAcceptableError {
+	e := new(NotAcceptableError)
+	e.HTTPCode = http.StatusNotAcceptable
+	e.ErrorCode = 6
+	e.ErrorMsg = "Not acceptable"
+	e.wrap(cause)
+
+	return e
+}
+
+// UnauthorizedError is the HTTP 401 error equivalent.
+type UnauthorizedError struct {
+	CustomError
+}
+
+// ErrorUnauthorized creates UnauthorizedError
+func ErrorUnauthorized(cause ...error) *UnauthorizedError {
+	e := new(UnauthorizedError)
+	e.HTTPCode = http.StatusUnauthorized
+	e.ErrorCode = 7
+	e.ErrorMsg = "Unauthorized"
+	e.wrap(cause)
+
+	return e
+}
+
+// PaymentRequiredError is the HTTP 402 error equivalent.
+type PaymentRequiredError struct {
+	CustomError
+}
+
+// ErrorPaymentRequired creates PaymentRequiredError
+func ErrorPaymentRequired(cause ...error) *PaymentRequiredError {
+	e := new(PaymentRequiredError)
+	e.HTTPCode = http.StatusPaymentRequired
+	e.ErrorCode = 8
+	e.ErrorMsg = "Payment required"
+	e.wrap(cause)
+
+	return e
+}
+
+// ForbiddenError is the HTTP 403 error equivalent.
+type ForbiddenError struct {
+	CustomError
+}
+
+// ErrorForbidden creates ForbiddenError
+func ErrorForbidden(cause ...error) *ForbiddenError {
+	e := new(ForbiddenError)
+	e.HTTPCode = http.StatusForbidden
+	e.ErrorCode = 9
+	e.ErrorMsg = "Forbidden"
+	e.wrap(cause)
+
+	return e
+}
+
+// ProxyAuthRequiredError is the HTTP 407 error equivalent.
+type ProxyAuthRequiredError struct {
+	CustomError
+}
+
+// ErrorProxyAuthRequired creates ProxyAuthRequiredError
+func ErrorProxyAuthRequired(cause ...error) *ProxyAuthRequiredError {
+	e := new(ProxyAuthRequiredError)
+	e.HTTPCode = http.StatusProxyAuthRequired
+	e.ErrorCode = 10
+	e.ErrorMsg = "Proxy authentication required"
+	e.wrap(cause)
+
+	return e
+}
+
+// RequestTimeoutError is the HTTP 408 error equivalent.
+type RequestTimeoutError struct {
+	CustomError
+}
+
+// ErrorRequestTimeout creates RequestTimeoutError
+func ErrorRequestTimeout(cause ...error) *RequestTimeoutError {
+	e := new(RequestTimeoutError)
+	e.HTTPCode = http.StatusRequestTimeout
+	e.ErrorCode = 11
+	e.ErrorMsg = "Request timeout"
+	e.wrap(cause)
+
+	return e
+}
+
+// ConflictError is the HTTP 409 error equivalent.
+type ConflictError struct {
+	CustomError
+}
+
+// ErrorConflict creates ConflictError
+func ErrorConflict(cause ...error) *ConflictError {
+	e := new(ConflictError)
+	e.HTTPCode = http.StatusConflict
+	e.ErrorCode = 12
+	e.ErrorMsg = "Conflict"
+	e.wrap(cause)
+
+	return e
+}
+
+// GoneError is the HTTP 410 error equivalent.
+type GoneError struct {
+	CustomError
+}
+
+// ErrorGone creates GoneError
+func ErrorGone(cause ...error) *GoneError {
+	e := new(GoneError)
+	e.HTTPCode = http.StatusGone
+	e.ErrorCode = 13
+	e.ErrorMsg = "Gone"
+	e.wrap(cause)
+
+	return e
+}
+
+// LengthRequiredError is the HTTP 411 error equivalent.
+type LengthRequiredError struct {
+	CustomError
+}
+
+// ErrorLengthRequired creates LengthRequiredError
+func ErrorLengthRequired(cause ...error) *LengthRequiredError {
+	e := new(LengthRequiredError)
+	e.HTTPCode = http.StatusLengthRequired
+	e.ErrorCode = 14
+	e.ErrorMsg = "Length required"
+	e.wrap(cause)
+
+	return e
+}
+
+// PreconditionFailedError is the HTTP 412 error equivalent.
+type PreconditionFailedError struct {
+	CustomError
+}
+
+// ErrorPreconditionFailed creates PreconditionFailedError
+func ErrorPreconditionFailed(cause ...error) *PreconditionFailedError {
+	e := new(PreconditionFailedError)
+	e.HTTPCode = http.StatusPreconditionFailed
+	e.ErrorCode = 15
+	e.ErrorMsg = "Precondition failed"
+	e.wrap(cause)
+
+	return e
+}
+
+// RequestEntityTooLargeError is the HTTP 413 error equivalent.
+type RequestEntityTooLargeError struct {
+	CustomError
+}
+
+// ErrorRequestEntityTooLarge creates RequestEntityTooLargeError
+func ErrorRequestEntityTooLarge(cause ...error) *RequestEntityTooLargeError {
+	e := new(RequestEntityTooLargeError)
+	e.HTTPCode = http.StatusRequestEntityTooLarge
+	e.ErrorCode = 16
+	e.ErrorMsg = "Request entity too large"
+	e.wrap(cause)
+
+	return e
+}
+
+// RequestURITooLongError is the HTTP 414 error equivalent.
+type RequestURITooLongError struct {
+	CustomError
+}
+
+// ErrorRequestURITooLong creates RequestURITooLongError
+func ErrorRequestURITooLong(cause ...error) *RequestURITooLongError {
+	e := new(RequestURITooLongError)
+	e.HTTPCode = http.StatusRequestURITooLong
+	e.ErrorCode = 17
+	e.ErrorMsg = "Request URI too long"
+	e.wrap(cause)
+
+	return e
+}
+
+// RequestedRangeNotSatisfiableError is the HTTP 416 error equivalent.
+type RequestedRangeNotSatisfiableError struct {
+	CustomError
+}
+
+// ErrorRequestedRangeNotSatisfiable creates RequestedRangeNotSatisfiableError
+func ErrorRequestedRangeNotSatisfiable(cause ...error) *RequestedRangeNotSatisfiableError {
+	e := new(RequestedRangeNotSatisfiableError)
+	e.HTTPCode = http.StatusRequestedRangeNotSatisfiable
+	e.ErrorCode = 18
+	e.ErrorMsg = "Requested range not satisfiable"
+	e.wrap(cause)
+
+	return e
+}
+
+// ExpectationFailedError is the HTTP 417 error equivalent.
+type ExpectationFailedError struct {
+	CustomError
+}
+
+// ErrorExpectationFailed creates ExpectationFailedError
+func ErrorExpectationFailed(cause ...error) *ExpectationFailedError {
+	e := new(ExpectationFailedError)
+	e.HTTPCode = http.StatusExpectationFailed
+	e.ErrorCode = 19
+	e.ErrorMsg = "Expectation failed"
+	e.wrap(cause)
+
+	return e
+}
+
+// TeapotError is the HTTP 418 error equivalent.
+type TeapotError struct {
+	CustomError
+}
+
+// ErrorTeapot creates TeapotError
+func ErrorTeapot(cause ...error) *TeapotError {
+	e := new(TeapotError)
+	e.HTTPCode = http.StatusTeapot
+	e.ErrorCode = 20
+	e.ErrorMsg = "I'm a teapot"
+	e.wrap(cause)
+
+	return e
+}
+
+// MisdirectedRequestError is the HTTP 421 error equivalent.
+type MisdirectedRequestError struct {
+	CustomError
+}
+
+// ErrorMisdirectedRequest creates MisdirectedRequestError
+func ErrorMisdirectedRequest(cause ...error) *MisdirectedRequestError {
+	e := new(MisdirectedRequestError)
+	e.HTTPCode = http.StatusMisdirectedRequest
+	e.ErrorCode = 21
+	e.ErrorMsg = "Misdirected request"
+	e.wrap(cause)
+
+	return e
+}
+
+// UnprocessableEntityError is the HTTP 422 error equivalent.
+type UnprocessableEntityError struct {
+	CustomError
+}
+
+// ErrorUnprocessableEntity creates UnprocessableEntityError
+func ErrorUnprocessableEntity(cause ...error) *UnprocessableEntityError {
+	e := new(UnprocessableEntityError)
+	e.HTTPCode = http.StatusUnprocessableEntity
+	e.ErrorCode = 22
+	e.ErrorMsg = "Unprocessable entity"
+	e.wrap(cause)
+
+	return e
+}
+
+// LockedError is the HTTP 423 error equivalent.
+type LockedError struct {
+	CustomError
+}
+
+// ErrorLocked creates LockedError
+func ErrorLocked(cause ...error) *LockedError {
+	e := new(LockedError)
+	e.HTTPCode = http.StatusLocked
+	e.ErrorCode = 23
+	e.ErrorMsg = "Locked"
+	e.wrap(cause)
+
+	return e
+}
+
+// FailedDependencyError is the HTTP 424 error equivalent.
+type FailedDependencyError struct {
+	CustomError
+}
+
+// ErrorFailedDependency creates FailedDependencyError
+func ErrorFailedDependency(cause ...error) *FailedDependencyError {
+	e := new(FailedDependencyError)
+	e.HTTPCode = http.StatusFailedDependency
+	e.ErrorCode = 24
+	e.ErrorMsg = "Failed dependency"
+	e.wrap(cause)
+
+	return e
+}
+
+// TooEarlyError is the HTTP 425 error equivalent.
+type TooEarlyError struct {
+	CustomError
+}
+
+// ErrorTooEarly creates TooEarlyError
+func ErrorTooEarly(cause ...error) *TooEarlyError {
+	e := new(TooEarlyError)
+	e.HTTPCode = http.StatusTooEarly
+	e.ErrorCode = 25
+	e.ErrorMsg = "Too early"
+	e.wrap(cause)
+
+	return e
+}
+
+// UpgradeRequiredError is the HTTP 426 error equivalent.
+type UpgradeRequiredError struct {
+	CustomError
+}
+
+// ErrorUpgradeRequired creates UpgradeRequiredError
+func ErrorUpgradeRequired(cause ...error) *UpgradeRequiredError {
+	e := new(UpgradeRequiredError)
+	e.HTTPCode = http.StatusUpgradeRequired
+	e.ErrorCode = 26
+	e.ErrorMsg = "Upgrade required"
+	e.wrap(cause)
+
+	return e
+}
+
+// PreconditionRequiredError is the HTTP 428 error equivalent.
+type PreconditionRequiredError struct {
+	CustomError
+}
+
+// ErrorPreconditionRequired creates PreconditionRequiredError
+func ErrorPreconditionRequired(cause ...error) *PreconditionRequiredError {
+	e := new(PreconditionRequiredError)
+	e.HTTPCode = http.StatusPreconditionRequired
+	e.ErrorCode = 27
+	e.ErrorMsg = "Precondition required"
+	e.wrap(cause)
+
+	return e
+}
+
+// TooManyRequestsError is the HTTP 429 error equivalent.
+type TooManyRequestsError struct {
+	CustomError
+}
+
+// ErrorTooManyRequests creates TooManyRequestsError
+func ErrorTooManyRequests(cause ...error) *TooManyRequestsError {
+	e := new(TooManyRequestsError)
+	e.HTTPCode = http.StatusTooManyRequests
+	e.ErrorCode = 28
+	e.ErrorMsg = "Too many requests"
+	e.wrap(cause)
+
+	return e
+}
+
+// RequestHeaderFieldsTooLargeError is the HTTP 431 error equivalent.
+type RequestHeaderFieldsTooLargeError struct {
+	CustomError
+}
+
+// ErrorRequestHeaderFieldsTooLarge creates RequestHeaderFieldsTooLargeError
+func ErrorRequestHeaderFieldsTooLarge(cause ...error) *RequestHeaderFieldsTooLargeError {
+	e := new(RequestHeaderFieldsTooLargeError)
+	e.HTTPCode = http.StatusRequestHeaderFieldsTooLarge
+	e.ErrorCode = 29
+	e.ErrorMsg = "Request header fields too large"
+	e.wrap(cause)
+
+	return e
+}
+
+// UnavailableForLegalReasonsError is the HTTP 451 error equivalent.
+type UnavailableForLegalReasonsError struct {
+	CustomError
+}
+
+// ErrorUnavailableForLegalReasons creates UnavailableForLegalReasonsError
+func ErrorUnavailableForLegalReasons(cause ...error) *UnavailableForLegalReasonsError {
+	e := new(UnavailableForLegalReasonsError)
+	e.HTTPCode = http.StatusUnavailableForLegalReasons
+	e.ErrorCode = 30
+	e.ErrorMsg = "Unavailable for legal reasons"
+	e.wrap(cause)
+
+	return e
+}
+
+// NotImplementedError is the HTTP 501 error equivalent.
+type NotImplementedError struct {
+	CustomError
+}
+
+// ErrorNotImplemented creates NotImplementedError
+func ErrorNotImplemented(cause ...error) *NotImplementedError {
+	e := new(NotImplementedError)
+	e.HTTPCode = http.StatusNotImplemented
+	e.ErrorCode = 31
+	e.ErrorMsg = "Not implemented"
+	e.wrap(cause)
 
 	return e
 }
+
+// BadGatewayError is the HTTP 502 error equivalent.
+type BadGatewayError struct {
+	CustomError
+}
+
+// ErrorBadGateway creates BadGatewayError
+func ErrorBadGateway(cause ...error) *BadGatewayError {
+	e := new(BadGatewayError)
+	e.HTTPCode = http.StatusBadGateway
+	e.ErrorCode = 32
+	e.ErrorMsg = "Bad gateway"
+	e.wrap(cause)
+
+	return e
+}
+
+// ServiceUnavailableError is the HTTP 503 error equivalent.
+type ServiceUnavailableError struct {
+	CustomError
+}
+
+// ErrorServiceUnavailable creates ServiceUnavailableError
+func ErrorServiceUnavailable(cause ...error) *ServiceUnavailableError {
+	e := new(ServiceUnavailableError)
+	e.HTTPCode = http.StatusServiceUnavailable
+	e.ErrorCode = 33
+	e.ErrorMsg = "Service unavailable"
+	e.wrap(cause)
+
+	return e
+}
+
+// GatewayTimeoutError is the HTTP 504 error equivalent.
+type GatewayTimeoutError struct {
+	CustomError
+}
+
+// ErrorGatewayTimeout creates GatewayTimeoutError
+func ErrorGatewayTimeout(cause ...error) *GatewayTimeoutError {
+	e := new(GatewayTimeoutError)
+	e.HTTPCode = http.StatusGatewayTimeout
+	e.ErrorCode = 34
+	e.ErrorMsg = "Gateway timeout"
+	e.wrap(cause)
+
+	return e
+}
+
+// HTTPVersionNotSupportedError is the HTTP 505 error equivalent.
+type HTTPVersionNotSupportedError struct {
+	CustomError
+}
+
+// ErrorHTTPVersionNotSupported creates HTTPVersionNotSupportedError
+func ErrorHTTPVersionNotSupported(cause ...error) *HTTPVersionNotSupportedError {
+	e := new(HTTPVersionNotSupportedError)
+	e.HTTPCode = http.StatusHTTPVersionNotSupported
+	e.ErrorCode = 35
+	e.ErrorMsg = "HTTP version not supported"
+	e.wrap(cause)
+
+	return e
+}
+
+// VariantAlsoNegotiatesError is the HTTP 506 error equivalent.
+type VariantAlsoNegotiatesError struct {
+	CustomError
+}
+
+// ErrorVariantAlsoNegotiates creates VariantAlsoNegotiatesError
+func ErrorVariantAlsoNegotiates(cause ...error) *VariantAlsoNegotiatesError {
+	e := new(VariantAlsoNegotiatesError)
+	e.HTTPCode = http.StatusVariantAlsoNegotiates
+	e.ErrorCode = 36
+	e.ErrorMsg = "Variant also negotiates"
+	e.wrap(cause)
+
+	return e
+}
+
+// InsufficientStorageError is the HTTP 507 error equivalent.
+type InsufficientStorageError struct {
+	CustomError
+}
+
+// ErrorInsufficientStorage creates InsufficientStorageError
+func ErrorInsufficientStorage(cause ...error) *InsufficientStorageError {
+	e := new(InsufficientStorageError)
+	e.HTTPCode = http.StatusInsufficientStorage
+	e.ErrorCode = 37
+	e.ErrorMsg = "Insufficient storage"
+	e.wrap(cause)
+
+	return e
+}
+
+// LoopDetectedError is the HTTP 508 error equivalent.
+type LoopDetectedError struct {
+	CustomError
+}
+
+// ErrorLoopDetected creates LoopDetectedError
+func ErrorLoopDetected(cause ...error) *LoopDetectedError {
+	e := new(LoopDetectedError)
+	e.HTTPCode = http.StatusLoopDetected
+	e.ErrorCode = 38
+	e.ErrorMsg = "Loop detected"
+	e.wrap(cause)
+
+	return e
+}
+
+// NotExtendedError is the HTTP 510 error equivalent.
+type NotExtendedError struct {
+	CustomError
+}
+
+// ErrorNotExtended creates NotExtendedError
+func ErrorNotExtended(cause ...error) *NotExtendedError {
+	e := new(NotExtendedError)
+	e.HTTPCode = http.StatusNotExtended
+	e.ErrorCode = 39
+	e.ErrorMsg = "Not extended"
+	e.wrap(cause)
+
+	return e
+}
+
+// NetworkAuthenticationRequiredError is the HTTP 511 error equivalent.
+type NetworkAuthenticationRequiredError struct {
+	CustomError
+}
+
+// ErrorNetworkAuthenticationRequired creates NetworkAuthenticationRequiredError
+func ErrorNetworkAuthenticationRequired(cause ...error) *NetworkAuthenticationRequiredError {
+	e := new(NetworkAuthenticationRequiredError)
+	e.HTTPCode = http.StatusNetworkAuthenticationRequired
+	e.ErrorCode = 40
+	e.ErrorMsg = "Network authentication required"
+	e.wrap(cause)
+
+	return e
+}
+
+// PanicError is the 500 error sent when a panic is recovered during the request flow.
+// It wraps the panic value when it's an error, and keeps the stack trace where the panic happened.
+type PanicError struct {
+	CustomError
+	Value interface{} // Value passed to panic()
+	stack []byte
+}
+
+// newPanicError creates the PanicError for a recovered panic value.
+func newPanicError(v interface{}, stack []byte) *PanicError {
+	e := &PanicError{Value: v, stack: stack}
+	e.HTTPCode = http.StatusInternalServerError
+	e.ErrorCode = 41
+	e.ErrorMsg = "Panic"
+
+	if err, ok := v.(error); ok {
+		e.Err = err
+	} else {
+		e.Err = fmt.Errorf("%v", v)
+	}
+
+	return e
+}
+
+// Stack returns the stack trace of the goroutine that panicked.
+func (e *PanicError) Stack() []byte {
+	return e.stack
+}
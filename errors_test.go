@@ -1,6 +1,8 @@
 package yarf
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -21,4 +23,120 @@ func TestErrors(t *testing.T) {
 	if e == nil {
 		t.Error("ErrorNotFound() should return an object. Nil value returned.")
 	}
+
+	e = ErrorBadRequest()
+	if e == nil || e.Code() != 400 {
+		t.Error("ErrorBadRequest() should return a 400 error object.")
+	}
+
+	e = ErrorUnsupportedMediaType()
+	if e == nil || e.Code() != 415 {
+		t.Error("ErrorUnsupportedMediaType() should return a 415 error object.")
+	}
+
+	e = ErrorNotAcceptable()
+	if e == nil || e.Code() != 406 {
+		t.Error("ErrorNotAcceptable() should return a 406 error object.")
+	}
+}
+
+func TestErrorCatalogue(t *testing.T) {
+	tests := []struct {
+		err  YError
+		code int
+	}{
+		{ErrorUnauthorized(), 401},
+		{ErrorPaymentRequired(), 402},
+		{ErrorForbidden(), 403},
+		{ErrorProxyAuthRequired(), 407},
+		{ErrorRequestTimeout(), 408},
+		{ErrorConflict(), 409},
+		{ErrorGone(), 410},
+		{ErrorLengthRequired(), 411},
+		{ErrorPreconditionFailed(), 412},
+		{ErrorRequestEntityTooLarge(), 413},
+		{ErrorRequestURITooLong(), 414},
+		{ErrorRequestedRangeNotSatisfiable(), 416},
+		{ErrorExpectationFailed(), 417},
+		{ErrorTeapot(), 418},
+		{ErrorMisdirectedRequest(), 421},
+		{ErrorUnprocessableEntity(), 422},
+		{ErrorLocked(), 423},
+		{ErrorFailedDependency(), 424},
+		{ErrorTooEarly(), 425},
+		{ErrorUpgradeRequired(), 426},
+		{ErrorPreconditionRequired(), 428},
+		{ErrorTooManyRequests(), 429},
+		{ErrorRequestHeaderFieldsTooLarge(), 431},
+		{ErrorUnavailableForLegalReasons(), 451},
+		{ErrorNotImplemented(), 501},
+		{ErrorBadGateway(), 502},
+		{ErrorServiceUnavailable(), 503},
+		{ErrorGatewayTimeout(), 504},
+		{ErrorHTTPVersionNotSupported(), 505},
+		{ErrorVariantAlsoNegotiates(), 506},
+		{ErrorInsufficientStorage(), 507},
+		{ErrorLoopDetected(), 508},
+		{ErrorNotExtended(), 510},
+		{ErrorNetworkAuthenticationRequired(), 511},
+	}
+
+	ids := make(map[int]bool)
+	for _, test := range tests {
+		if test.err.Code() != test.code {
+			t.Errorf("%T should be a %d error, %d found", test.err, test.code, test.err.Code())
+		}
+		if ids[test.err.ID()] {
+			t.Errorf("%T error ID %d is already used", test.err, test.err.ID())
+		}
+		ids[test.err.ID()] = true
+	}
+}
+
+func TestErrorCause(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := ErrorServiceUnavailable(cause)
+
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is should find the error cause")
+	}
+	if err.Error() != "Service unavailable: connection refused" {
+		t.Errorf("Error() should include the cause, '%s' found", err.Error())
+	}
+	if err.Msg() != "Service unavailable" {
+		t.Errorf("Msg() shouldn't include the cause, '%s' found", err.Msg())
+	}
+
+	var target *ServiceUnavailableError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &target) || target != err {
+		t.Error("errors.As should find wrapped YErrors")
+	}
+
+	if ErrorConflict().Unwrap() != nil {
+		t.Error("Errors without cause should unwrap to nil")
+	}
+}
+
+func TestAsYError(t *testing.T) {
+	if AsYError(nil) != nil {
+		t.Error("AsYError(nil) should return nil")
+	}
+
+	notFound := ErrorNotFound()
+	if AsYError(fmt.Errorf("loading: %w", notFound)) != notFound {
+		t.Error("AsYError() should return the YError in the error chain")
+	}
+
+	yerr := AsYError(errors.New("plain"))
+	if yerr.Code() != 500 || yerr.Msg() != "plain" || yerr.Body() != "" {
+		t.Errorf("AsYError() should convert plain errors to 500 errors without body, %d '%s' found", yerr.Code(), yerr.Body())
+	}
+	if !errors.Is(yerr.(error), yerr.(*CustomError).Err) {
+		t.Error("Converted errors should wrap the original error")
+	}
+
+	yerr = AsYError(&RenderError{Format: "JSON", Err: errors.New("unsupported type")})
+	if _, ok := yerr.(*UnexpectedError); !ok || yerr.Body() != "" {
+		t.Errorf("AsYError() should convert render errors to UnexpectedError without body, %T '%s' found", yerr, yerr.Body())
+	}
 }
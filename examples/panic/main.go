@@ -20,11 +20,9 @@ func (p *Panic) Get(c *yarf.Context) error {
 	//return nil
 }
 
-// PanicHandler is used to catch panics and display the error message
-func PanicHandler() {
-	if err := recover(); err != nil {
-		fmt.Printf("Handling panic: %v \n", err)
-	}
+// RecoverHandler is called with the recovered panics to display the error message
+func RecoverHandler(c *yarf.Context, v interface{}, stack []byte) {
+	fmt.Printf("Handling panic: %v \n%s", v, stack)
 }
 
 // Entry point of the executable application
@@ -37,7 +35,7 @@ func main() {
 	y.Add("/", new(Panic))
 
 	// Set our custom panic handler
-	y.PanicHandler = PanicHandler
+	y.RecoverHandler = RecoverHandler
 
 	// Start server listening on port 8080
 	y.Start(":8080")
@@ -0,0 +1,120 @@
+package yarf
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Matcher checks a request condition, other than its path, for a route to match.
+// Requests not accepted by a route matcher fall through to the next matching route.
+type Matcher func(*http.Request) bool
+
+// When sets request matchers for the route. All of them have to accept the request for the route to match.
+// Routes with matchers aren't stored into the route cache, as they depend on more than the request path.
+//
+//	y.Add("/users", new(UsersV2), yarf.When(yarf.MatchAccept("application/vnd.app.v2+json")))
+//	y.Add("/users", new(Users))
+func When(matchers ...Matcher) RouteOption {
+	return func(r *route) {
+		r.matchers = append(r.matchers, matchers...)
+	}
+}
+
+// accepts returns true if all the route matchers accept the request.
+func (r *route) accepts(req *http.Request) bool {
+	if req == nil {
+		return false
+	}
+
+	for _, m := range r.matchers {
+		if !m(req) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatchHeader accepts requests with the header set to value.
+// If value is empty, it accepts requests where the header is present.
+func MatchHeader(name, value string) Matcher {
+	return func(req *http.Request) bool {
+		values, ok := req.Header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return false
+		}
+		if value == "" {
+			return true
+		}
+
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// MatchQuery accepts requests with the query param set to value, as in ?format=csv.
+// If value is empty, it accepts requests where the query param is present.
+func MatchQuery(name, value string) Matcher {
+	return func(req *http.Request) bool {
+		values, ok := req.URL.Query()[name]
+		if !ok {
+			return false
+		}
+		if value == "" {
+			return true
+		}
+
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// MatchContentType accepts requests with a body of any of the media types provided.
+// Media type params, like charset, are ignored.
+func MatchContentType(types ...string) Matcher {
+	return func(req *http.Request) bool {
+		t, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil {
+			return false
+		}
+
+		for _, v := range types {
+			if strings.EqualFold(t, v) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// MatchAccept accepts requests listing the media type in the Accept header,
+// as used on versioned APIs: application/vnd.app.v2+json
+// Media type params, like q, are ignored.
+func MatchAccept(mediaType string) Matcher {
+	return func(req *http.Request) bool {
+		for _, h := range req.Header["Accept"] {
+			for _, v := range strings.Split(h, ",") {
+				if i := strings.IndexByte(v, ';'); i >= 0 {
+					v = v[:i]
+				}
+				if strings.EqualFold(strings.TrimSpace(v), mediaType) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+}
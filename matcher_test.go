@@ -0,0 +1,76 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		matcher Matcher
+		header  string
+		value   string
+		url     string
+		result  bool
+	}{
+		{MatchHeader("X-Version", "2"), "X-Version", "2", "/", true},
+		{MatchHeader("X-Version", "2"), "X-Version", "1", "/", false},
+		{MatchHeader("X-Version", ""), "X-Version", "1", "/", true},
+		{MatchHeader("X-Version", ""), "X-Other", "1", "/", false},
+		{MatchQuery("format", "csv"), "", "", "/?format=csv", true},
+		{MatchQuery("format", "csv"), "", "", "/?format=json", false},
+		{MatchQuery("format", ""), "", "", "/?format", true},
+		{MatchQuery("format", ""), "", "", "/", false},
+		{MatchContentType("application/json"), "Content-Type", "application/json; charset=utf-8", "/", true},
+		{MatchContentType("text/xml", "application/xml"), "Content-Type", "application/xml", "/", true},
+		{MatchContentType("application/json"), "Content-Type", "text/plain", "/", false},
+		{MatchContentType("application/json"), "", "", "/", false},
+		{MatchAccept("application/vnd.app.v2+json"), "Accept", "text/html, application/vnd.app.v2+json;q=0.9", "/", true},
+		{MatchAccept("application/vnd.app.v2+json"), "Accept", "application/vnd.app.v1+json", "/", false},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+test.url, nil)
+		if test.header != "" {
+			req.Header.Set(test.header, test.value)
+		}
+
+		if test.matcher(req) != test.result {
+			t.Errorf("Matcher for request %s %s: %s should return %v", test.url, test.header, test.value, test.result)
+		}
+	}
+}
+
+func TestRouteMatchers(t *testing.T) {
+	y := New()
+	y.Add("/users", &MockNameResource{name: "v2"}, When(MatchAccept("application/vnd.app.v2+json")))
+	y.Add("/users", &MockNameResource{name: "csv"}, When(MatchQuery("format", "csv")))
+	y.Add("/users", &MockNameResource{name: "default"})
+
+	tests := []struct {
+		url    string
+		accept string
+		body   string
+	}{
+		{"/users", "application/vnd.app.v2+json", "v2:"},
+		{"/users?format=csv", "", "csv:"},
+		{"/users", "", "default:"},
+		{"/users", "application/vnd.app.v2+json", "v2:"},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+test.url, nil)
+		req.Header.Set("Accept", test.accept)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Body.String() != test.body {
+			t.Errorf("Request to %s with Accept '%s' should render '%s', '%s' found", test.url, test.accept, test.body, res.Body.String())
+		}
+	}
+
+	if y.Cache.(*LRUCache).Len() != 0 {
+		t.Error("Routes depending on request matchers shouldn't be cached")
+	}
+}
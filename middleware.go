@@ -1,5 +1,11 @@
 package yarf
 
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+)
+
 // MiddlewareHandler interface provides the methods for request filters
 // that needs to run before, or after, every request Resource is executed.
 type MiddlewareHandler interface {
@@ -27,3 +33,84 @@ func (m *Middleware) PostDispatch(c *Context) error {
 func (m *Middleware) End(c *Context) error {
 	return nil
 }
+
+// dispatchMiddleware runs a dispatch function wrapped by a list of middleware.
+// PreDispatch runs in order before the dispatch and PostDispatch in order after it.
+// Any error stops the flow, but End always runs for all of them.
+// Panics are recovered and returned as PanicError, so End runs for them too.
+func dispatchMiddleware(middleware []MiddlewareHandler, c *Context, dispatch func(*Context) error) (err error) {
+	err = runMiddleware(middleware, c, dispatch)
+
+	// End dispatch, even if there were errors.
+	// A panic on End replaces the returned error, unless it was a panic too.
+	var perr *PanicError
+	if e := endMiddleware(middleware, c); e != nil && !errors.As(err, &perr) {
+		err = e
+	}
+
+	return
+}
+
+// runMiddleware runs the PreDispatch, dispatch and PostDispatch steps, stopping on the first error.
+// A panic in any of them is converted into a PanicError.
+func runMiddleware(middleware []MiddlewareHandler, c *Context, dispatch func(*Context) error) (err error) {
+	defer recoverPanic(&err)
+
+	// Pre-dispatch middleware
+	for _, m := range middleware {
+		err = m.PreDispatch(c)
+		if err != nil {
+			return
+		}
+	}
+
+	err = dispatch(c)
+	if err != nil {
+		return
+	}
+
+	// Post-dispatch middleware
+	for _, m := range middleware {
+		err = m.PostDispatch(c)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// endMiddleware runs the End method of a list of middleware.
+// End errors are ignored to be sure we go through all middlewares, but panics are recovered
+// and the first one is returned as PanicError.
+func endMiddleware(middleware []MiddlewareHandler, c *Context) (err error) {
+	for _, m := range middleware {
+		if e := end(m, c); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// end runs the End method of a middleware, returning panics as PanicError.
+func end(m MiddlewareHandler, c *Context) (err error) {
+	defer recoverPanic(&err)
+
+	m.End(c)
+
+	return nil
+}
+
+// recoverPanic converts a panic into a PanicError stored into err.
+// It has to be deferred by the function returning err.
+func recoverPanic(err *error) {
+	if v := recover(); v != nil {
+		// Let net/http abort the response
+		if v == http.ErrAbortHandler {
+			panic(v)
+		}
+
+		*err = newPanicError(v, debug.Stack())
+	}
+}
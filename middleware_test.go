@@ -3,6 +3,7 @@ package yarf
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -35,3 +36,59 @@ func TestMiddlewareDefaultResponse(t *testing.T) {
 		t.Error("Default PostDispatch() implementation should return nil")
 	}
 }
+
+type MockLogMiddleware struct {
+	name string
+	log  *[]string
+	err  error
+}
+
+func (m *MockLogMiddleware) PreDispatch(c *Context) error {
+	*m.log = append(*m.log, m.name+"-pre")
+	return m.err
+}
+
+func (m *MockLogMiddleware) PostDispatch(c *Context) error {
+	*m.log = append(*m.log, m.name+"-post")
+	return nil
+}
+
+func (m *MockLogMiddleware) End(c *Context) error {
+	*m.log = append(*m.log, m.name+"-end")
+	return nil
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	var log []string
+
+	g := RouteGroup("/admin")
+	g.Insert(&MockLogMiddleware{name: "group", log: &log})
+	g.Add("/open", new(MockResource))
+	g.Add("/users", Handlers{
+		"GET": func(c *Context) error {
+			log = append(log, "resource")
+			return nil
+		},
+	}, Use(&MockLogMiddleware{name: "auth", log: &log}, &MockLogMiddleware{name: "audit", log: &log}))
+	g.Add("/denied", new(MockResource), Use(&MockLogMiddleware{name: "deny", log: &log, err: ErrorNotFound()}))
+
+	y := New()
+	y.AddGroup(g)
+
+	tests := map[string]string{
+		"/admin/users":  "group-pre auth-pre audit-pre resource auth-post audit-post auth-end audit-end group-post group-end",
+		"/admin/open":   "group-pre group-end",
+		"/admin/denied": "group-pre deny-pre deny-end group-end",
+	}
+
+	for url, expected := range tests {
+		log = nil
+
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+url, nil)
+		y.ServeHTTP(httptest.NewRecorder(), req)
+
+		if strings.Join(log, " ") != expected {
+			t.Errorf("Middleware flow for '%s' should be '%s', '%s' found", url, expected, strings.Join(log, " "))
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package yarf
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mount adds a standard http.Handler to serve all requests under the url prefix.
+// The prefix, including the ones from parent groups, is removed from the request path before calling the handler,
+// and the group middleware runs around it as it does for any other route.
+// As Yarf implements http.Handler, it can be used to mount independently built apps into a single one:
+//
+//	y.Mount("/static", http.FileServer(http.Dir("/var/www/public")))
+//	y.Mount("/billing", billing.New())
+func (g *GroupRoute) Mount(prefix string, h http.Handler) {
+	r := &route{
+		path:       prefix,
+		routeParts: append(prepareURL(prefix), "*"),
+		mount:      h,
+	}
+	for _, m := range httpMethods {
+		r.methods = append(r.methods, m.method)
+	}
+	r.init()
+
+	g.addRoute(r)
+}
+
+// serveMount calls the mounted http.Handler with a copy of the request, stripping the route prefix from its path.
+func (r *route) serveMount(c *Context) error {
+	path := "/" + c.tail
+	if c.tail != "" && strings.HasSuffix(c.Request.URL.Path, "/") {
+		path += "/"
+	}
+
+	req := new(http.Request)
+	*req = *c.Request
+	req.URL = new(url.URL)
+	*req.URL = *c.Request.URL
+	req.URL.Path = path
+	req.URL.RawPath = ""
+
+	r.mount.ServeHTTP(c.Response, req)
+
+	return nil
+}
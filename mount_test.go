@@ -0,0 +1,77 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type MockHeaderMiddleware struct {
+	Middleware
+}
+
+func (m *MockHeaderMiddleware) PreDispatch(c *Context) error {
+	c.Response.Header().Set("X-Middleware", "pre")
+	return nil
+}
+
+func TestMount(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	})
+
+	g := RouteGroup("/api/:version")
+	g.Insert(new(MockHeaderMiddleware))
+	g.Mount("/echo", echo)
+
+	y := New()
+	y.AddGroup(g)
+
+	tests := map[string]string{
+		"/api/v1/echo":            "/",
+		"/api/v1/echo/":           "/",
+		"/api/v1/echo/a/b":        "/a/b",
+		"/api/v1/echo/dir/":       "/dir/",
+		"/api/v1/echo//a//b.html": "/a/b.html",
+	}
+
+	// Run twice to check cached routes
+	for i := 0; i < 2; i++ {
+		for url, path := range tests {
+			req, _ := http.NewRequest("PROPFIND", "http://localhost:8080"+url, nil)
+			res := httptest.NewRecorder()
+			y.ServeHTTP(res, req)
+
+			if res.Body.String() != "PROPFIND "+path {
+				t.Errorf("Request to '%s' should reach the mounted handler as '%s', '%s' found", url, path, res.Body.String())
+			}
+			if res.Header().Get("X-Middleware") != "pre" {
+				t.Errorf("Group middleware should run for mounted handler on '%s'", url)
+			}
+		}
+	}
+}
+
+func TestMountYarf(t *testing.T) {
+	sub := New()
+	sub.Add("/hello/:name", &MockNameResource{name: "sub"})
+
+	y := New()
+	y.Mount("/sub", sub)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/sub/hello/joe", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Body.String() != "sub:" {
+		t.Errorf("Mounted Yarf should serve '/sub/hello/joe', '%s' found", res.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/sub/nothing", nil)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 404 {
+		t.Errorf("Mounted Yarf should return 404 for unknown routes, %d found", res.Code)
+	}
+}
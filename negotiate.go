@@ -0,0 +1,197 @@
+package yarf
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Renderer encodes response data for a media type.
+type Renderer func(data interface{}) ([]byte, error)
+
+// renderer is a Renderer registered for a media type.
+type renderer struct {
+	mediaType   string // Media type, as type/subtype
+	contentType string // Content-Type header sent with the rendered data
+	render      Renderer
+}
+
+// renderers stores the registered renderers, in priority order.
+var renderers = struct {
+	list []renderer
+	sync.RWMutex
+}{
+	list: []renderer{
+		{"application/json", "application/json; charset=utf-8", json.Marshal},
+		{"application/xml", "application/xml; charset=utf-8", xml.Marshal},
+		{"text/plain", "text/plain; charset=utf-8", textRenderer},
+	},
+}
+
+// RegisterRenderer adds a Renderer used by Context.Negotiate() for a media type,
+// or replaces the one registered before.
+// Built-in renderers are application/json, application/xml and text/plain.
+// The contentType is sent on the Content-Type header, it defaults to the media type when empty.
+// New renderers have lower priority than the existing ones when the client accepts them equally.
+func RegisterRenderer(mediaType, contentType string, r Renderer) {
+	mediaType = strings.ToLower(mediaType)
+	if contentType == "" {
+		contentType = mediaType
+	}
+
+	renderers.Lock()
+	defer renderers.Unlock()
+
+	for i := range renderers.list {
+		if renderers.list[i].mediaType == mediaType {
+			renderers.list[i] = renderer{mediaType, contentType, r}
+			return
+		}
+	}
+
+	renderers.list = append(renderers.list, renderer{mediaType, contentType, r})
+}
+
+// textRenderer renders strings, byte slices and fmt.Stringer values as they are.
+// Other values use their default format.
+func textRenderer(data interface{}) ([]byte, error) {
+	switch v := data.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	}
+
+	return []byte(fmt.Sprint(data)), nil
+}
+
+// Negotiate renders the data with the registered Renderer preferred by the client Accept header,
+// as set by RegisterRenderer().
+// It sets the Content-Type header, and adds Accept to the Vary header.
+// Requests without an Accept header get the first registered renderer: JSON.
+// It returns a 406 YError if the client doesn't accept any of the renderers,
+// or a *RenderError if the data can't be encoded, without writing anything to the response.
+func (c *Context) Negotiate(data interface{}) error {
+	c.Response.Header().Add("Vary", "Accept")
+
+	renderers.RLock()
+	list := renderers.list
+	renderers.RUnlock()
+
+	types := make([]string, len(list))
+	for i, r := range list {
+		types[i] = r.mediaType
+	}
+
+	i := negotiate(c.Request.Header.Get("Accept"), types)
+	if i < 0 {
+		e := ErrorNotAcceptable()
+		e.ErrorBody = "Supported media types: " + strings.Join(types, ", ")
+		return e
+	}
+
+	body, err := list[i].render(data)
+	if err != nil {
+		return &RenderError{Format: list[i].mediaType, Err: err}
+	}
+
+	c.Response.Header().Set("Content-Type", list[i].contentType)
+	return c.write(body)
+}
+
+// acceptRange is a media range from an Accept header.
+type acceptRange struct {
+	mediaType   string  // Type and subtype, any of them can be *
+	q           float64 // Quality value
+	specificity int     // Ranges with less wildcards take precedence
+}
+
+// parseAccept parses an Accept header value.
+// Ranges with invalid quality values are ignored.
+func parseAccept(accept string) []acceptRange {
+	var list []acceptRange
+
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+
+		r := acceptRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		if r.mediaType == "" {
+			continue
+		}
+
+		valid := true
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if !strings.HasPrefix(p, "q=") && !strings.HasPrefix(p, "Q=") {
+				r.specificity++
+				continue
+			}
+
+			q, err := strconv.ParseFloat(p[2:], 64)
+			if err != nil || q < 0 || q > 1 {
+				valid = false
+				break
+			}
+			r.q = q
+		}
+		if !valid {
+			continue
+		}
+
+		switch {
+		case r.mediaType == "*/*" || r.mediaType == "*":
+		case strings.HasSuffix(r.mediaType, "/*"):
+			r.specificity += 10
+		default:
+			r.specificity += 20
+		}
+
+		list = append(list, r)
+	}
+
+	return list
+}
+
+// matches returns true if the media range includes the media type.
+func (r acceptRange) matches(mediaType string) bool {
+	if r.mediaType == "*/*" || r.mediaType == "*" || r.mediaType == mediaType {
+		return true
+	}
+
+	return strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(mediaType, r.mediaType[:len(r.mediaType)-1])
+}
+
+// negotiate returns the position of the media type preferred by an Accept header,
+// or -1 if none is acceptable.
+// Each media type takes the quality of the most specific range including it.
+// Types accepted with the same quality keep the list order.
+// An empty Accept header accepts the first type.
+func negotiate(accept string, types []string) int {
+	if strings.TrimSpace(accept) == "" {
+		if len(types) == 0 {
+			return -1
+		}
+		return 0
+	}
+
+	ranges := parseAccept(accept)
+
+	best, bestQ := -1, 0.0
+	for i, t := range types {
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if r.specificity > specificity && r.matches(t) {
+				q, specificity = r.q, r.specificity
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+
+	return best
+}
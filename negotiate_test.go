@@ -0,0 +1,144 @@
+package yarf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateAccept(t *testing.T) {
+	types := []string{"application/json", "application/xml", "text/plain"}
+
+	tests := []struct {
+		accept   string
+		expected int
+	}{
+		{"", 0},
+		{"*/*", 0},
+		{"application/xml", 1},
+		{"text/*", 2},
+		{"application/*", 0},
+		{"application/json;q=0.5, application/xml", 1},
+		{"application/json;q=0.5, application/xml;q=0.5", 0},
+		{"text/html, application/xhtml+xml, application/xml;q=0.9, */*;q=0.8", 1},
+		{"*/*;q=0.1, application/json;q=0", 1},
+		{"text/plain;format=flowed, application/json;q=0.9", 2},
+		{"TEXT/PLAIN", 2},
+		{"application/xml;q=invalid, text/plain;q=0.2", 2},
+		{"text/html", -1},
+		{"application/json;q=0", -1},
+	}
+
+	for _, test := range tests {
+		if i := negotiate(test.accept, types); i != test.expected {
+			t.Errorf("Accept '%s' should select %d, %d found", test.accept, test.expected, i)
+		}
+	}
+}
+
+type MockNegotiateData struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func (d MockNegotiateData) String() string {
+	return "name: " + d.Name
+}
+
+func TestNegotiate(t *testing.T) {
+	data := MockNegotiateData{Name: "yarf"}
+
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "application/json; charset=utf-8", `{"name":"yarf"}`},
+		{"application/xml", "application/xml; charset=utf-8", `<MockNegotiateData><name>yarf</name></MockNegotiateData>`},
+		{"text/plain", "text/plain; charset=utf-8", `name: yarf`},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "http://localhost/", nil)
+		req.Header.Set("Accept", test.accept)
+		res := httptest.NewRecorder()
+
+		c := NewContext(req, res)
+		c.Status(201)
+		if err := c.Negotiate(data); err != nil {
+			t.Errorf("Negotiate() for '%s' returned an error: %s", test.accept, err)
+			continue
+		}
+
+		header := res.Result().Header
+		if res.Code != 201 || header.Get("Content-Type") != test.contentType {
+			t.Errorf("Response for '%s' should be 201 with Content-Type '%s', %d '%s' found", test.accept, test.contentType, res.Code, header.Get("Content-Type"))
+		}
+		if header.Get("Vary") != "Accept" {
+			t.Errorf("Vary header should be 'Accept', '%s' found", header.Get("Vary"))
+		}
+		if res.Body.String() != test.body {
+			t.Errorf("Body for '%s' should be '%s', '%s' found", test.accept, test.body, res.Body.String())
+		}
+	}
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost/", nil)
+	req.Header.Set("Accept", "image/png")
+	res := httptest.NewRecorder()
+
+	c := NewContext(req, res)
+	err := c.Negotiate("data")
+
+	if yerr, ok := err.(YError); !ok || yerr.Code() != 406 {
+		t.Errorf("Negotiate() should return a 406 error when nothing is acceptable, %v found", err)
+	}
+	if res.Body.Len() > 0 {
+		t.Errorf("Negotiate() shouldn't write anything on errors, '%s' found", res.Body.String())
+	}
+}
+
+func TestNegotiateEncodingError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost/", nil)
+	res := httptest.NewRecorder()
+
+	c := NewContext(req, res)
+	err := c.Negotiate(make(chan int))
+
+	if err == nil {
+		t.Error("Negotiate() should return encoding errors")
+	}
+	if res.Body.Len() > 0 || c.Writer().Written() {
+		t.Error("Negotiate() shouldn't write anything on encoding errors")
+	}
+}
+
+var errFail = errors.New("fail")
+
+func TestRegisterRenderer(t *testing.T) {
+	RegisterRenderer("application/x-test", "", func(data interface{}) ([]byte, error) {
+		return []byte("test"), nil
+	})
+	RegisterRenderer("application/x-fail", "application/x-fail; v=1", func(data interface{}) ([]byte, error) {
+		return nil, errFail
+	})
+
+	req, _ := http.NewRequest("GET", "http://localhost/", nil)
+	req.Header.Set("Accept", "application/x-test, application/json;q=0.5")
+	res := httptest.NewRecorder()
+
+	c := NewContext(req, res)
+	if err := c.Negotiate("data"); err != nil {
+		t.Fatalf("Negotiate() returned an error: %s", err)
+	}
+	if res.Header().Get("Content-Type") != "application/x-test" || res.Body.String() != "test" {
+		t.Errorf("Registered renderer should be used, '%s' '%s' found", res.Header().Get("Content-Type"), res.Body.String())
+	}
+
+	req.Header.Set("Accept", "application/x-fail")
+	c = NewContext(req, httptest.NewRecorder())
+	if err := c.Negotiate("data"); !errors.Is(err, errFail) {
+		t.Errorf("Registered renderer errors should be returned, %v found", err)
+	}
+}
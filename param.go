@@ -0,0 +1,113 @@
+package yarf
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ParamType validates a route param value and converts it to its typed representation.
+// It returns false when the value doesn't satisfy the type,
+// so the request falls through to the next matching route.
+type ParamType func(value string) (interface{}, bool)
+
+// paramTypes stores the registered param types by name.
+var paramTypes = struct {
+	types map[string]ParamType
+	sync.RWMutex
+}{
+	types: map[string]ParamType{
+		"int":   intParam,
+		"float": floatParam,
+		"alpha": alphaParam,
+		"uuid":  uuidParam,
+	},
+}
+
+// RegisterParamType adds a new param type to be used on route definitions as :name<type>.
+// Built-in types are:
+//   - int		// Integer numbers, stored as int.
+//   - float		// Floating point numbers, stored as float64.
+//   - alpha		// Letters only.
+//   - uuid		// UUID strings in the 8-4-4-4-12 hex format.
+//   - regex:expr	// Values matching the full regular expression.
+//
+// Param types should be registered before adding the routes that use them.
+func RegisterParamType(name string, t ParamType) {
+	paramTypes.Lock()
+	defer paramTypes.Unlock()
+
+	paramTypes.types[name] = t
+}
+
+// paramTypeFor returns the ParamType for a type constraint definition.
+func paramTypeFor(spec string) (ParamType, error) {
+	if strings.HasPrefix(spec, "regex:") {
+		re, err := regexp.Compile("^(?:" + spec[len("regex:"):] + ")$")
+		if err != nil {
+			return nil, err
+		}
+
+		return func(value string) (interface{}, bool) {
+			return value, re.MatchString(value)
+		}, nil
+	}
+
+	paramTypes.RLock()
+	defer paramTypes.RUnlock()
+
+	t, ok := paramTypes.types[spec]
+	if !ok {
+		return nil, errors.New("unknown param type '" + spec + "'")
+	}
+
+	return t, nil
+}
+
+// intParam accepts integer values.
+func intParam(value string) (interface{}, bool) {
+	i, err := strconv.Atoi(value)
+	return i, err == nil
+}
+
+// floatParam accepts floating point values.
+func floatParam(value string) (interface{}, bool) {
+	f, err := strconv.ParseFloat(value, 64)
+	return f, err == nil
+}
+
+// alphaParam accepts values composed by letters only.
+func alphaParam(value string) (interface{}, bool) {
+	for _, r := range value {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// uuidParam accepts UUID values in their canonical 8-4-4-4-12 form.
+func uuidParam(value string) (interface{}, bool) {
+	if len(value) != 36 {
+		return nil, false
+	}
+
+	for i, r := range value {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return nil, false
+			}
+
+		default:
+			if (r < '0' || r > '9') && (r < 'a' || r > 'f') && (r < 'A' || r > 'F') {
+				return nil, false
+			}
+		}
+	}
+
+	return value, true
+}
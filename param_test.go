@@ -0,0 +1,102 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParamTypes(t *testing.T) {
+	tests := []struct {
+		route string
+		match []string
+		fail  []string
+	}{
+		{"/users/:id<int>", []string{"/users/1", "/users/-20"}, []string{"/users/a", "/users/1.5", "/users/"}},
+		{"/prices/:value<float>", []string{"/prices/1", "/prices/1.5"}, []string{"/prices/one"}},
+		{"/names/:name<alpha>", []string{"/names/Joe"}, []string{"/names/Joe1", "/names/_"}},
+		{"/v/:uuid<uuid>", []string{"/v/123e4567-e89b-12d3-a456-426614174000"}, []string{"/v/123e4567e89b12d3a456426614174000", "/v/xyz"}},
+		{"/files/:name<regex:[a-z]+\\.txt>", []string{"/files/readme.txt"}, []string{"/files/readme.md", "/files/README.txt", "/files/a.txt.bak"}},
+	}
+
+	for _, test := range tests {
+		r := Route(test.route, new(Handler))
+
+		for _, s := range test.match {
+			if !r.Match(s, &Context{Params: Params{}}) {
+				t.Errorf("'%s' should match against '%s'", s, test.route)
+			}
+		}
+		for _, s := range test.fail {
+			if r.Match(s, &Context{Params: Params{}}) {
+				t.Errorf("'%s' shouldn't match against '%s'", s, test.route)
+			}
+		}
+	}
+}
+
+func TestParamTypeInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Route with an unknown param type should panic")
+		}
+	}()
+
+	Route("/users/:id<unknown>", new(Handler))
+}
+
+func TestRegisterParamType(t *testing.T) {
+	RegisterParamType("even", func(value string) (interface{}, bool) {
+		i, ok := intParam(value)
+		return i, ok && i.(int)%2 == 0
+	})
+
+	r := Route("/numbers/:n<even>", new(Handler))
+
+	if !r.Match("/numbers/2", &Context{Params: Params{}}) {
+		t.Error("'/numbers/2' should match against '/numbers/:n<even>'")
+	}
+	if r.Match("/numbers/3", &Context{Params: Params{}}) {
+		t.Error("'/numbers/3' shouldn't match against '/numbers/:n<even>'")
+	}
+}
+
+type MockParamResource struct {
+	Resource
+	id  int
+	val interface{}
+}
+
+func (r *MockParamResource) Get(c *Context) error {
+	r.id = c.ParamInt("id")
+	r.val = c.ParamValue("id")
+	return nil
+}
+
+func TestParamTypeFallThrough(t *testing.T) {
+	typed := new(MockParamResource)
+	other := new(MockParamResource)
+
+	y := New()
+	y.Add("/users/:id<int>", typed)
+	y.Add("/users/:id", other)
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "http://localhost:8080/users/42", nil)
+		y.ServeHTTP(httptest.NewRecorder(), req)
+
+		if typed.id != 42 {
+			t.Errorf("ParamInt() should return 42, %d found", typed.id)
+		}
+		if v, ok := typed.val.(int); !ok || v != 42 {
+			t.Errorf("ParamValue() should return the int value 42, %v found", typed.val)
+		}
+
+		req, _ = http.NewRequest("GET", "http://localhost:8080/users/joe", nil)
+		y.ServeHTTP(httptest.NewRecorder(), req)
+
+		if other.val != "joe" {
+			t.Errorf("Non int param should fall through to '/users/:id', %v found", other.val)
+		}
+	}
+}
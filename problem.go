@@ -0,0 +1,143 @@
+package yarf
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"sort"
+)
+
+// Problem is a RFC 7807 problem details object, used to send machine-readable error responses.
+// Extension members are added to the top level of the object along the standard ones.
+type Problem struct {
+	Type       string                 // URI reference identifying the problem type. Defaults to about:blank
+	Title      string                 // Short summary of the problem type
+	Status     int                    // HTTP status code
+	Detail     string                 // Explanation of this occurrence of the problem
+	Instance   string                 // URI reference identifying this occurrence of the problem
+	Extensions map[string]interface{} // Extension members
+}
+
+// ProblemError is implemented by errors adding their own details to the Problem built from them.
+type ProblemError interface {
+	Problem(p *Problem)
+}
+
+// NewProblem creates the Problem for a YError.
+// The title is the HTTP status text, and the detail is the error Body(), if any.
+// Non-zero error IDs are added as the code extension member.
+// Errors implementing ProblemError can change any of the Problem fields.
+func NewProblem(c *Context, err YError) *Problem {
+	p := &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(err.Code()),
+		Status: err.Code(),
+		Detail: err.Body(),
+	}
+
+	if c != nil && c.Request != nil {
+		p.Instance = c.Request.URL.Path
+	}
+
+	if err.ID() != 0 {
+		p.Extensions = map[string]interface{}{"code": err.ID()}
+	}
+
+	if pe, ok := err.(ProblemError); ok {
+		pe.Problem(p)
+	}
+
+	return p
+}
+
+// Problem sets the validation failure as detail, and adds the field errors as the errors extension member.
+func (e *ValidationError) Problem(p *Problem) {
+	p.Detail = e.ErrorMsg
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions["errors"] = e.Fields
+}
+
+// members returns the standard and extension members of the Problem, skipping empty optional ones.
+// Extension members can't replace standard ones.
+func (p *Problem) members() map[string]interface{} {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	m["title"] = p.Title
+	m["status"] = p.Status
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+
+	return m
+}
+
+// MarshalJSON encodes the Problem as application/problem+json
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.members())
+}
+
+// MarshalXML encodes the Problem as application/problem+xml, as described by RFC 7807 Appendix A.
+// Members are encoded in alphabetical order.
+func (p *Problem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	m := p.members()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := e.EncodeElement(m[k], xml.StartElement{Name: xml.Name{Local: k}}); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// problemTypes are the media types a Problem can be rendered as, in priority order.
+// Clients accepting plain JSON or XML get the problem variant of them.
+var problemTypes = []string{"application/problem+json", "application/problem+xml", "application/json", "application/xml"}
+
+// RenderProblem writes a YError response as RFC 7807 problem details, including the status code.
+// The format is negotiated with the Accept header: application/problem+xml for clients preferring XML,
+// application/problem+json otherwise.
+func (c *Context) RenderProblem(err YError) error {
+	p := NewProblem(c, err)
+
+	var body []byte
+	var encErr error
+	contentType := "application/problem+json"
+
+	if i := negotiate(c.Request.Header.Get("Accept"), problemTypes); i == 1 || i == 3 {
+		contentType = "application/problem+xml"
+		body, encErr = xml.Marshal(p)
+	} else {
+		body, encErr = json.Marshal(p)
+	}
+	if encErr != nil {
+		return &RenderError{Format: contentType, Err: encErr}
+	}
+
+	c.Response.Header().Set("Content-Type", contentType)
+	c.Response.Header().Add("Vary", "Accept")
+	c.Response.WriteHeader(p.Status)
+
+	return c.write(body)
+}
@@ -0,0 +1,110 @@
+package yarf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewProblem(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost:8080/users/1?x=1", nil)
+	c := NewContext(req, httptest.NewRecorder())
+
+	p := NewProblem(c, ErrorNotFound())
+	if p.Type != "about:blank" || p.Title != "Not Found" || p.Status != 404 || p.Instance != "/users/1" {
+		t.Errorf("Problem wasn't built correctly: %+v", p)
+	}
+	if p.Extensions["code"] != 2 {
+		t.Errorf("Problem should include the error ID as code, %v found", p.Extensions["code"])
+	}
+
+	e := &CustomError{HTTPCode: 409, ErrorBody: "User already exists"}
+	p = NewProblem(c, e)
+	if p.Detail != "User already exists" || p.Extensions != nil {
+		t.Errorf("Problem detail should be the error body, without code for empty IDs: %+v", p)
+	}
+}
+
+func TestProblemJSON(t *testing.T) {
+	p := &Problem{
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "You do not have enough credit.",
+		Status:     403,
+		Extensions: map[string]interface{}{"balance": 30, "title": "ignored"},
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"balance":30,"status":403,"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit"}`
+	if string(body) != expected {
+		t.Errorf("Problem JSON should be '%s', '%s' found", expected, body)
+	}
+}
+
+func TestProblemResponse(t *testing.T) {
+	y := New()
+	y.ProblemDetails = true
+	y.Add("/test", new(MockResource))
+
+	tests := []struct {
+		url         string
+		accept      string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"/none", "", 404, "application/problem+json", `{"code":2,"instance":"/none","status":404,"title":"Not Found","type":"about:blank"}`},
+		{"/none", "text/html", 404, "application/problem+json", `{"code":2,"instance":"/none","status":404,"title":"Not Found","type":"about:blank"}`},
+		{"/test", "application/json", 405, "application/problem+json", `{"code":1,"instance":"/test","status":405,"title":"Method Not Allowed","type":"about:blank"}`},
+		{"/test", "application/xml", 405, "application/problem+xml", `<problem xmlns="urn:ietf:rfc:7807"><code>1</code><instance>/test</instance><status>405</status><title>Method Not Allowed</title><type>about:blank</type></problem>`},
+		{"/test", "application/problem+xml, application/json;q=0.5", 405, "application/problem+xml", ""},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+test.url, nil)
+		req.Header.Set("Accept", test.accept)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Code != test.code {
+			t.Errorf("Request to '%s' should return %d, %d found", test.url, test.code, res.Code)
+		}
+		if res.Header().Get("Content-Type") != test.contentType {
+			t.Errorf("Accept '%s' should get '%s', '%s' found", test.accept, test.contentType, res.Header().Get("Content-Type"))
+		}
+		if test.body != "" && res.Body.String() != test.body {
+			t.Errorf("Problem body should be '%s', '%s' found", test.body, res.Body.String())
+		}
+	}
+}
+
+func TestProblemValidationError(t *testing.T) {
+	y := New()
+	y.ProblemDetails = true
+	y.Add("/users", new(MockValidateResource))
+
+	req, _ := http.NewRequest("POST", "http://localhost:8080/users", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 422 {
+		t.Errorf("Validation errors should return 422, %d found", res.Code)
+	}
+
+	var p struct {
+		Detail string       `json:"detail"`
+		Errors []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Invalid problem JSON: %s", err)
+	}
+	if p.Detail != "Validation failed" || len(p.Errors) != 4 {
+		t.Errorf("Validation problem should list the field errors, %s found", res.Body.String())
+	}
+}
@@ -1,5 +1,12 @@
 package yarf
 
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
 // The ResourceHandler interface defines how Resources through the application have to be defined.
 // Ideally, the developer will composite the Resource struct into their own resources,
 // but it's possible to implement each one by their own.
@@ -78,3 +85,231 @@ func (r *Resource) Trace(c *Context) error {
 func (r *Resource) Connect(c *Context) error {
 	return ErrorMethodNotImplemented()
 }
+
+// HandlerFunc is a function handling a single HTTP method of a resource.
+type HandlerFunc func(*Context) error
+
+// Handlers is a ResourceHandler implemented by functions, mapped by HTTP method name.
+// Method names are case-insensitive, and unknown ones make the route creation panic.
+// It avoids declaring a resource struct for simple endpoints:
+//
+//	y.Add("/health", yarf.Handlers{
+//		"GET": func(c *yarf.Context) error {
+//			c.Render("OK")
+//			return nil
+//		},
+//	})
+//
+// Methods without a function return a MethodNotImplementedError.
+type Handlers map[string]HandlerFunc
+
+// call executes the function for the HTTP method.
+func (h Handlers) call(method string, c *Context) error {
+	if f := h.lookup(method); f != nil {
+		return f(c)
+	}
+
+	return ErrorMethodNotImplemented()
+}
+
+// lookup returns the function for the HTTP method.
+// Method names are upper-cased by validate() when the route is created.
+func (h Handlers) lookup(method string) HandlerFunc {
+	return h[method]
+}
+
+// validate upper-cases the method names, so they are matched case-insensitively.
+// It panics if a method name isn't a HTTP method, as routes are defined on startup.
+func (h Handlers) validate() {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+
+	for _, k := range keys {
+		m := strings.ToUpper(k)
+		if !isHTTPMethod(m) {
+			panic(fmt.Sprintf("yarf: unknown HTTP method '%s' in Handlers", k))
+		}
+
+		if m != k {
+			if _, ok := h[m]; !ok {
+				h[m] = h[k]
+			}
+			delete(h, k)
+		}
+	}
+}
+
+// isHTTPMethod reports if the upper-case method name is a HTTP method.
+func isHTTPMethod(method string) bool {
+	for _, m := range httpMethods {
+		if m.method == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get calls the GET function
+func (h Handlers) Get(c *Context) error {
+	return h.call("GET", c)
+}
+
+// Post calls the POST function
+func (h Handlers) Post(c *Context) error {
+	return h.call("POST", c)
+}
+
+// Put calls the PUT function
+func (h Handlers) Put(c *Context) error {
+	return h.call("PUT", c)
+}
+
+// Patch calls the PATCH function
+func (h Handlers) Patch(c *Context) error {
+	return h.call("PATCH", c)
+}
+
+// Delete calls the DELETE function
+func (h Handlers) Delete(c *Context) error {
+	return h.call("DELETE", c)
+}
+
+// Options calls the OPTIONS function
+func (h Handlers) Options(c *Context) error {
+	return h.call("OPTIONS", c)
+}
+
+// Head calls the HEAD function
+func (h Handlers) Head(c *Context) error {
+	return h.call("HEAD", c)
+}
+
+// Trace calls the TRACE function
+func (h Handlers) Trace(c *Context) error {
+	return h.call("TRACE", c)
+}
+
+// Connect calls the CONNECT function
+func (h Handlers) Connect(c *Context) error {
+	return h.call("CONNECT", c)
+}
+
+// Methods returns the HTTP methods with a function set, to implement MethodLister.
+func (h Handlers) Methods() (methods []string) {
+	for _, m := range httpMethods {
+		if h.lookup(m.method) != nil {
+			methods = append(methods, m.method)
+		}
+	}
+
+	return
+}
+
+// MethodLister can be implemented by resources to report the HTTP methods they handle.
+// Otherwise, the methods are detected from the ones overriding the default Resource implementation.
+type MethodLister interface {
+	Methods() []string
+}
+
+// httpMethods lists the HTTP methods handled by ResourceHandler, with their method names.
+var httpMethods = []struct {
+	method string
+	name   string
+}{
+	{"GET", "Get"},
+	{"POST", "Post"},
+	{"PUT", "Put"},
+	{"PATCH", "Patch"},
+	{"DELETE", "Delete"},
+	{"OPTIONS", "Options"},
+	{"HEAD", "Head"},
+	{"TRACE", "Trace"},
+	{"CONNECT", "Connect"},
+}
+
+// resourceType is the type declaring the default method implementations.
+var resourceType = reflect.TypeOf(Resource{})
+
+// implementedMethods returns the HTTP methods implemented by a ResourceHandler.
+// Methods promoted from an embedded Resource aren't considered implemented.
+func implementedMethods(h ResourceHandler) (methods []string) {
+	if l, ok := h.(MethodLister); ok {
+		return l.Methods()
+	}
+
+	t := reflect.TypeOf(h)
+	for _, m := range httpMethods {
+		if declaringType(t, m.name) != resourceType {
+			methods = append(methods, m.method)
+		}
+	}
+
+	return
+}
+
+// declaringType returns the type declaring the method name for t,
+// following the methods promoted from embedded structs.
+// It returns nil if the method can't be found.
+func declaringType(t reflect.Type, name string) reflect.Type {
+	dt, _ := promotedFrom(t, name)
+	return dt
+}
+
+// promotedFrom returns the type declaring the method name for t
+// and the embedding depth where it was found.
+// As Go does, the shallowest embedded field wins.
+func promotedFrom(t reflect.Type, name string) (reflect.Type, int) {
+	if t.Kind() == reflect.Ptr {
+		if declares(t, name) || declares(t.Elem(), name) {
+			return t.Elem(), 0
+		}
+		t = t.Elem()
+	} else if declares(t, name) {
+		return t, 0
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, 0
+	}
+
+	// Promoted method, look for it in the embedded fields.
+	var found reflect.Type
+	depth := 0
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.Anonymous {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() != reflect.Ptr {
+			ft = reflect.PtrTo(ft)
+		}
+		if _, ok := ft.MethodByName(name); !ok {
+			continue
+		}
+
+		if dt, d := promotedFrom(ft, name); found == nil || d+1 < depth {
+			found, depth = dt, d+1
+		}
+	}
+
+	return found, depth
+}
+
+// declares returns true if the method name is written for t,
+// and not generated by the compiler to promote it from an embedded type.
+func declares(t reflect.Type, name string) bool {
+	m, ok := t.MethodByName(name)
+	if !ok {
+		return false
+	}
+
+	pc := m.Func.Pointer()
+	file, _ := runtime.FuncForPC(pc).FileLine(pc)
+
+	return file != "<autogenerated>"
+}
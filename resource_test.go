@@ -3,6 +3,7 @@ package yarf
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -50,3 +51,109 @@ func TestResourceInterfaceMethods(t *testing.T) {
 	}
 
 }
+
+type MockGetResource struct {
+	Resource
+}
+
+func (r *MockGetResource) Get(c *Context) error {
+	return nil
+}
+
+type MockGetPostResource struct {
+	MockGetResource
+}
+
+func (r *MockGetPostResource) Post(c *Context) error {
+	return nil
+}
+
+type MockListerResource struct {
+	Resource
+}
+
+func (r *MockListerResource) Methods() []string {
+	return []string{"PUT"}
+}
+
+func TestImplementedMethods(t *testing.T) {
+	tests := []struct {
+		h       ResourceHandler
+		methods string
+	}{
+		{new(Resource), ""},
+		{new(MockResource), ""},
+		{new(MockGetResource), "GET"},
+		{new(MockGetPostResource), "GET,POST"},
+		{new(MockListerResource), "PUT"},
+		{Handlers{"POST": func(c *Context) error { return nil }, "GET": nil}, "POST"},
+	}
+
+	for _, test := range tests {
+		methods := strings.Join(implementedMethods(test.h), ",")
+		if methods != test.methods {
+			t.Errorf("%T should implement '%s', '%s' found", test.h, test.methods, methods)
+		}
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	g := RouteGroup("/api")
+	g.Insert(new(MockHeaderMiddleware))
+	g.Add("/health", Handlers{
+		"GET": func(c *Context) error {
+			c.Render("OK")
+			return nil
+		},
+	})
+
+	y := New()
+	y.AddGroup(g)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/api/health", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Body.String() != "OK" {
+		t.Errorf("GET function should render 'OK', '%s' found", res.Body.String())
+	}
+	if res.Header().Get("X-Middleware") != "pre" {
+		t.Error("Group middleware should run for Handlers resources")
+	}
+
+	req, _ = http.NewRequest("POST", "http://localhost:8080/api/health", nil)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 405 {
+		t.Errorf("Method without function should return 405, %d found", res.Code)
+	}
+	if res.Header().Get("Allow") != "GET, OPTIONS, HEAD" {
+		t.Errorf("Allow header should be 'GET, OPTIONS, HEAD', '%s' found", res.Header().Get("Allow"))
+	}
+}
+
+func TestHandlersMethodCase(t *testing.T) {
+	y := New()
+	y.Add("/health", Handlers{
+		"get": func(c *Context) error {
+			c.Render("OK")
+			return nil
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/health", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Body.String() != "OK" {
+		t.Errorf("Method names should be case-insensitive, %d '%s' found", res.Code, res.Body.String())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Handlers with an unknown method name should panic")
+		}
+	}()
+	y.Add("/typo", Handlers{"GETT": func(c *Context) error { return nil }})
+}
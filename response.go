@@ -0,0 +1,152 @@
+package yarf
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter wraps the http.ResponseWriter of each request to keep track of the response status,
+// the amount of bytes written and if the headers were already sent.
+// It also implements http.Flusher, http.Hijacker, http.Pusher and io.ReaderFrom,
+// passing the calls to the original writer when it supports them.
+// As these interfaces are always implemented, use http.NewResponseController
+// to know if the original writer supports them. It reaches the original writer through Unwrap.
+type ResponseWriter struct {
+	http.ResponseWriter
+
+	status  int
+	size    int
+	written bool
+	before  []func(*ResponseWriter)
+}
+
+// NewResponseWriter wraps a http.ResponseWriter.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{
+		ResponseWriter: w,
+		status:         http.StatusOK,
+	}
+}
+
+// Status returns the HTTP status code sent, or the one to be sent if nothing was written yet.
+func (w *ResponseWriter) Status() int {
+	return w.status
+}
+
+// Size returns the amount of body bytes written.
+func (w *ResponseWriter) Size() int {
+	return w.size
+}
+
+// Written returns true if the response headers were already sent.
+func (w *ResponseWriter) Written() bool {
+	return w.written
+}
+
+// Before adds a function to be called right before the response headers are sent,
+// the last chance to change them.
+// Functions are called in the order they were added.
+func (w *ResponseWriter) Before(f func(*ResponseWriter)) {
+	w.before = append(w.before, f)
+}
+
+// Unwrap returns the original http.ResponseWriter, as used by http.ResponseController.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteHeader sets the status code of the response.
+// The headers are sent along the first Write or Flush call, or at the end of the request,
+// so they can still be changed after setting the status code, as the Render functions do with Content-Type.
+// The last status code set before sending the headers is used, and calls after that are ignored.
+// Informational (1xx) codes are sent as they are.
+func (w *ResponseWriter) WriteHeader(code int) {
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.written {
+		return
+	}
+
+	w.status = code
+}
+
+// WriteHeaderNow sends the response headers with the status code, if they weren't sent yet.
+// Yarf calls it at the end of each request, so responses without body get their status code.
+func (w *ResponseWriter) WriteHeaderNow() {
+	if !w.written {
+		w.writeHeader()
+	}
+}
+
+// writeHeader calls the before functions and sends the response headers.
+func (w *ResponseWriter) writeHeader() {
+	w.written = true
+
+	for _, f := range w.before {
+		f(w)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// Write sends the response headers, if not sent yet, and writes the content to the response body.
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeaderNow()
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+
+	return n, err
+}
+
+// Flush sends any buffered data to the client, if the original writer supports it.
+func (w *ResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.WriteHeaderNow()
+		f.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection, if the original writer supports it.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("yarf: the ResponseWriter doesn't support hijacking")
+	}
+
+	w.written = true
+
+	return h.Hijack()
+}
+
+// Push initiates an HTTP/2 server push, if the original writer supports it.
+func (w *ResponseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+
+	return http.ErrNotSupported
+}
+
+// ReadFrom writes the content read from r to the response body,
+// using the original writer ReadFrom, as sendfile does for files, when it supports it.
+func (w *ResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.WriteHeaderNow()
+
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		// Hide the ReadFrom method so io.Copy doesn't call it back
+		n, err = io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+	}
+	w.size += int(n)
+
+	return n, err
+}
@@ -0,0 +1,245 @@
+package yarf
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriterDefaults(t *testing.T) {
+	w := NewResponseWriter(httptest.NewRecorder())
+
+	if w.Status() != 200 {
+		t.Errorf("Default status should be 200, %d found", w.Status())
+	}
+	if w.Size() != 0 {
+		t.Errorf("Size should be 0 before writing, %d found", w.Size())
+	}
+	if w.Written() {
+		t.Error("Response shouldn't be written before writing")
+	}
+}
+
+func TestResponseWriterWrite(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := NewResponseWriter(res)
+
+	w.Write([]byte("hello"))
+	w.Write([]byte(" world"))
+
+	if !w.Written() {
+		t.Error("Response should be written after Write()")
+	}
+	if w.Size() != 11 {
+		t.Errorf("Size should be 11, %d found", w.Size())
+	}
+	if w.Status() != 200 || res.Code != 200 {
+		t.Errorf("Status should be 200, %d and %d found", w.Status(), res.Code)
+	}
+	if res.Body.String() != "hello world" {
+		t.Errorf("Body should be 'hello world', '%s' found", res.Body.String())
+	}
+}
+
+func TestResponseWriterWriteHeader(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := NewResponseWriter(res)
+
+	w.WriteHeader(500)
+	w.WriteHeader(201)
+
+	if w.Written() {
+		t.Error("Response shouldn't be written until the body is written")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+	w.WriteHeader(500)
+
+	if w.Status() != 201 || res.Code != 201 {
+		t.Errorf("The last status code set before writing should be sent, %d and %d found", w.Status(), res.Code)
+	}
+	if res.Result().Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Headers set after the status code should be sent, '%s' found", res.Result().Header.Get("Content-Type"))
+	}
+}
+
+func TestResponseWriterWriteHeaderNow(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := NewResponseWriter(res)
+
+	w.WriteHeader(204)
+	w.WriteHeaderNow()
+
+	if !w.Written() || res.Code != 204 {
+		t.Errorf("WriteHeaderNow() should send the status code, %d found", res.Code)
+	}
+}
+
+func TestResponseWriterInformational(t *testing.T) {
+	w := NewResponseWriter(httptest.NewRecorder())
+
+	w.WriteHeader(http.StatusEarlyHints)
+
+	if w.Written() {
+		t.Error("Informational status codes shouldn't mark the response as written")
+	}
+
+	w.WriteHeader(204)
+	if w.Status() != 204 {
+		t.Errorf("Status should be 204 after informational response, %d found", w.Status())
+	}
+}
+
+func TestResponseWriterBefore(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := NewResponseWriter(res)
+
+	var order []int
+	w.Before(func(w *ResponseWriter) {
+		order = append(order, 1)
+		w.Header().Set("X-Status", http.StatusText(w.Status()))
+	})
+	w.Before(func(w *ResponseWriter) {
+		order = append(order, 2)
+	})
+
+	w.WriteHeader(404)
+	w.Write([]byte("body"))
+
+	if len(order) != 2 || order[0] != 1 || order[1] != 2 {
+		t.Errorf("Before functions should run once, in order, %v found", order)
+	}
+	if res.Header().Get("X-Status") != "Not Found" {
+		t.Errorf("Before functions should be able to set headers, '%s' found", res.Header().Get("X-Status"))
+	}
+}
+
+func TestResponseWriterFlush(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := NewResponseWriter(res)
+
+	var f http.Flusher = w
+	f.Flush()
+
+	if !res.Flushed {
+		t.Error("Flush() should flush the original writer")
+	}
+	if !w.Written() {
+		t.Error("Response should be written after Flush()")
+	}
+}
+
+func TestResponseWriterPush(t *testing.T) {
+	w := NewResponseWriter(httptest.NewRecorder())
+
+	var p http.Pusher = w
+	if err := p.Push("/style.css", nil); err != http.ErrNotSupported {
+		t.Errorf("Push() should return http.ErrNotSupported for writers without push support, %v found", err)
+	}
+}
+
+type MockHijackResponse struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (r *MockHijackResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	return nil, nil, nil
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	w := NewResponseWriter(httptest.NewRecorder())
+	if _, _, err := w.Hijack(); err == nil {
+		t.Error("Hijack() should fail for writers without hijack support")
+	}
+
+	res := &MockHijackResponse{ResponseRecorder: httptest.NewRecorder()}
+	w = NewResponseWriter(res)
+
+	var h http.Hijacker = w
+	if _, _, err := h.Hijack(); err != nil {
+		t.Errorf("Hijack() should succeed, %v found", err)
+	}
+	if !res.hijacked {
+		t.Error("Hijack() should hijack the original writer")
+	}
+	if !w.Written() {
+		t.Error("Response should be written after Hijack()")
+	}
+}
+
+type MockReaderFromResponse struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+}
+
+func (r *MockReaderFromResponse) ReadFrom(src io.Reader) (int64, error) {
+	r.readFrom = true
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestResponseWriterReadFrom(t *testing.T) {
+	res := &MockReaderFromResponse{ResponseRecorder: httptest.NewRecorder()}
+	w := NewResponseWriter(res)
+	w.WriteHeader(201)
+
+	var rf io.ReaderFrom = w
+	if n, err := rf.ReadFrom(strings.NewReader("content")); n != 7 || err != nil {
+		t.Errorf("ReadFrom() should write 7 bytes, %d %v found", n, err)
+	}
+	if !res.readFrom {
+		t.Error("ReadFrom() should use the original writer ReadFrom")
+	}
+	if res.Code != 201 || res.Body.String() != "content" || w.Size() != 7 {
+		t.Errorf("ReadFrom() should send the status and content, %d '%s' %d found", res.Code, res.Body.String(), w.Size())
+	}
+
+	plain := httptest.NewRecorder()
+	w = NewResponseWriter(plain)
+	if _, err := w.ReadFrom(strings.NewReader("content")); err != nil || plain.Body.String() != "content" {
+		t.Errorf("ReadFrom() should copy the content for writers without ReadFrom, %v '%s' found", err, plain.Body.String())
+	}
+}
+
+func TestResponseWriterUnwrap(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := NewResponseWriter(res)
+
+	if w.Unwrap() != res {
+		t.Error("Unwrap() should return the original writer")
+	}
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		t.Errorf("http.ResponseController should flush through the wrapper, %v found", err)
+	}
+}
+
+type MockWrittenResource struct {
+	Resource
+}
+
+func (r *MockWrittenResource) Get(c *Context) error {
+	c.Render("partial")
+	return ErrorUnexpected()
+}
+
+func TestFinishAfterWritten(t *testing.T) {
+	y := New()
+	y.Add("/written", new(MockWrittenResource))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/written", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 200 {
+		t.Errorf("Errors returned after writing shouldn't change the status, %d found", res.Code)
+	}
+	if res.Body.String() != "partial" {
+		t.Errorf("Errors returned after writing shouldn't be written, '%s' found", res.Body.String())
+	}
+}
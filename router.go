@@ -2,7 +2,9 @@ package yarf
 
 import (
 	"errors"
+	"net/http"
 	"strings"
+	"sync"
 )
 
 // Router interface provides the methods used to handle route and GroupRoute objects.
@@ -14,62 +16,162 @@ type Router interface {
 // GroupRouter interface adds methods to work with children routers
 type GroupRouter interface {
 	Router
-	Add(string, ResourceHandler)
+	Add(string, ResourceHandler, ...RouteOption)
 	AddGroup(*GroupRoute)
+	Mount(string, http.Handler)
 	Insert(MiddlewareHandler)
+	Wrap(...func(http.Handler) http.Handler)
+	URL(string, ...string) (string, error)
+	Routes() []RouteInfo
+}
+
+// RouteOption configures optional route settings when it's created.
+type RouteOption func(*route)
+
+// Use sets middleware for a single route.
+// It runs in order, inside the middleware of the groups containing the route.
+func Use(m ...MiddlewareHandler) RouteOption {
+	return func(r *route) {
+		r.middleware = append(r.middleware, m...)
+	}
+}
+
+// Named sets the route name, used to build URLs to the route with Yarf.URL() and Context.URLFor().
+func Named(name string) RouteOption {
+	return func(r *route) {
+		r.name = name
+	}
 }
 
 // route struct stores the expected route path and the ResourceHandler that handles that route.
 type route struct {
 	path string // Original route
 
+	name string // Route name, used to build URLs
+
 	routeParts []string // parsed Route split into parts
 
+	segments []segment // parsed route parts
+
+	tree *node // Single route tree used by Match
+
 	handler ResourceHandler // Handler for the route
+
+	mount http.Handler // Handler for mounted routes, used instead of the ResourceHandler
+
+	matchers []Matcher // Request conditions for the route to match
+
+	middleware []MiddlewareHandler // Route middleware, running inside the group middleware
+
+	methods []string // HTTP methods implemented by the handler
+
+	allow string // Allow header value for the route
 }
 
 // Route returns a new route object initialized with the provided data.
 // Params:
 //	- url string 		// The route path to handle
 //	- h	ResourceHandler	// The ResourceHandler object that will process the requests to the url.
+//	- opts ...RouteOption	// Optional route settings, like Named().
 //
-func Route(url string, h ResourceHandler) Router {
-	return &route{
+func Route(url string, h ResourceHandler, opts ...RouteOption) Router {
+	r := &route{
 		path:       url,
 		handler:    h,
 		routeParts: prepareURL(url),
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if hs, ok := h.(Handlers); ok {
+		hs.validate()
+	}
+	r.methods = implementedMethods(h)
+	r.init()
+
+	return r
+}
+
+// init parses the route path and prepares the route for matching.
+func (r *route) init() {
+	r.segments = parseSegments(r.routeParts, true)
+	r.allow = allowHeader(r.methods)
+	r.tree = buildTree([]entry{{segs: r.segments, chain: []Router{r}}})
 }
 
 // Match returns true/false indicating if a request URL matches the route and
 // sets the Context Params for matching parts in the original route.
-// Route matchs are exact, that means, there are not optional parameters.
-// To implement optional parameters you can define different routes handled by the same ResourceHandler.
+// Route matchs are exact, unless the route defines optional params (/:param?)
+// or ends with a catch-all wildcard (/* or /*name).
+// Routes with request matchers, set using When(), only match if all of them accept the request.
 // When a route matches the request URL, this method will parse and fill
 // the parameters parsed during the process into the Context object.
 func (r *route) Match(url string, c *Context) bool {
-	requestParts := prepareURL(url)
-
-	// YARF router only accepts exact route matches, so check for part count.
-	// Unless it's a catch-all route
-	if len(r.routeParts) == 0 || (len(r.routeParts) > 0 && r.routeParts[len(r.routeParts)-1] != "*") {
-		if len(r.routeParts) != len(requestParts) {
-			return false
-		}
-	}
-
-	// check that requestParts matches routeParts
-	if !matches(r.routeParts, requestParts) {
+	m := r.tree.find(prepareURL(url), c.Request)
+	if m.leaf == nil {
 		return false
 	}
 
-	storeParams(c, r.routeParts, requestParts)
+	m.store(c)
 
 	return true
 }
 
 // Dispatch executes the right ResourceHandler method based on the HTTP request in the Context object.
+// OPTIONS requests are answered with the Allow header when the resource doesn't implement them,
+// and HEAD requests are served by the GET method discarding the response body.
+// When the method isn't implemented, the Allow header is set on the 405 response.
+// Route middleware, set using Use(), runs around it in the same way group middleware does.
 func (r *route) Dispatch(c *Context) error {
+	if len(r.middleware) == 0 {
+		return r.dispatchHandler(c)
+	}
+
+	return dispatchMiddleware(r.middleware, c, r.dispatchHandler)
+}
+
+// dispatchHandler executes the route handler for the request.
+func (r *route) dispatchHandler(c *Context) (err error) {
+	if r.mount != nil {
+		return r.serveMount(c)
+	}
+
+	switch {
+	case c.Request.Method == "OPTIONS" && !r.implements("OPTIONS"):
+		c.Response.Header().Set("Allow", r.allow)
+		return nil
+
+	case c.Request.Method == "HEAD" && !r.implements("HEAD") && r.implements("GET"):
+		rw := c.Response
+		c.Response = bodylessResponse{rw}
+		defer func() { c.Response = rw }()
+		err = r.handler.Get(c)
+
+	default:
+		err = r.dispatch(c)
+	}
+
+	var mni *MethodNotImplementedError
+	if errors.As(err, &mni) && c.Response != nil {
+		c.Response.Header().Set("Allow", r.allow)
+	}
+
+	return
+}
+
+// implements returns true if the route resource implements the HTTP method.
+func (r *route) implements(method string) bool {
+	for _, m := range r.methods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dispatch calls the ResourceHandler method for the request HTTP method.
+func (r *route) dispatch(c *Context) error {
 	// Method dispatch
 	switch c.Request.Method {
 	case "GET":
@@ -105,15 +207,63 @@ func (r *route) Dispatch(c *Context) error {
 	return ErrorMethodNotImplemented()
 }
 
+// allowHeader returns the Allow header value for the implemented methods,
+// including the ones answered automatically: HEAD when GET is implemented, and OPTIONS.
+func allowHeader(methods []string) string {
+	allowed := make(map[string]bool)
+	for _, m := range methods {
+		allowed[m] = true
+	}
+	allowed["HEAD"] = allowed["HEAD"] || allowed["GET"]
+	allowed["OPTIONS"] = true
+
+	list := make([]string, 0, len(httpMethods))
+	for _, m := range httpMethods {
+		if allowed[m.method] {
+			list = append(list, m.method)
+		}
+	}
+
+	return strings.Join(list, ", ")
+}
+
+// bodylessResponse discards the response body written, to serve HEAD requests.
+type bodylessResponse struct {
+	http.ResponseWriter
+}
+
+// Write discards the content, reporting it as written.
+func (w bodylessResponse) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
 // GroupRoute stores routes grouped under a single url prefix.
 type GroupRoute struct {
+	// Routes version, increased when routes are added to the group or its nested groups.
+	// First to keep it 64-bit aligned.
+	version uint64
+
 	prefix string // The url prefix path for all routes in the group
 
 	routeParts []string // parsed Route split into parts
 
 	middleware []MiddlewareHandler // Group middleware resources
 
+	wrappers []func(http.Handler) http.Handler // Standard net/http middleware wrapping the group dispatch
+
 	routes []Router // Group routes
+
+	hostname string // Host pattern the group routes are restricted to
+
+	host []segment // Parsed host pattern
+
+	errorHandler func(*Context, error) // Error handler for the group routes
+
+	parent *GroupRoute // Group containing this one, notified when routes are added
+
+	table        *routeTable  // Compiled routes
+	tableVersion uint64       // Routes version used to compile the table
+	tableLock    sync.RWMutex // Sync Mutex for the routes list and the table compilation
 }
 
 // RouteGroup creates a new GroupRoute object and initializes it with the provided url prefix.
@@ -128,101 +278,182 @@ func RouteGroup(url string) *GroupRoute {
 	}
 }
 
-// Match loops through all routes inside the group and find for one that matches the request.
-// After a match is found, the route matching is stored into Context.groupDispatch
+// Match looks for a route inside the group that matches the request.
+// All routes in the group, including the ones in nested groups, are compiled into a tree
+// the first time Match is called, so the lookup doesn't depend on the amount of routes.
+// The tree is compiled again when new routes are added.
+// When more than one route matches, the first one added wins, as if the routes were checked in order.
+// After a match is found, the routes chain is stored into Context.groupDispatch
 // to being able to dispatch it directly after a match without looping again.
 // Outside the box, works exactly the same as route.Match()
 func (g *GroupRoute) Match(url string, c *Context) bool {
-	urlParts := prepareURL(url)
-
-	// check if urlParts matches routeParts
-	if !matches(g.routeParts, urlParts) {
+	m := g.compile().tree.find(prepareURL(url), c.Request)
+	if m.leaf == nil {
 		return false
 	}
 
-	// Remove prefix part form the request URL
-	rURL := strings.Join(urlParts[len(g.routeParts):], "/")
+	// store the matching Routers and params after a match is found
+	if len(c.groupDispatch) == 0 {
+		c.groupDispatch = m.leaf.chain
+	} else {
+		c.groupDispatch = append(c.groupDispatch, m.leaf.chain...)
+	}
+	m.store(c)
 
-	// Now look for a match inside the routes collection
-	for _, r := range g.routes {
-		if r.Match(rURL, c) {
-			// store the matching Router and params after a match is found
-			c.groupDispatch = append(c.groupDispatch, r)
-			storeParams(c, g.routeParts, urlParts)
-			return true
+	return true
+}
+
+// compile returns the group compiled routes, building them if needed.
+func (g *GroupRoute) compile() *routeTable {
+	v := g.routesVersion()
+
+	g.tableLock.RLock()
+	t := g.table
+	ok := t != nil && g.tableVersion == v
+	g.tableLock.RUnlock()
+
+	if ok {
+		return t
+	}
+
+	g.tableLock.Lock()
+	defer g.tableLock.Unlock()
+
+	if g.table == nil || g.tableVersion != v {
+		g.table = newRouteTable(g.entries(g.routes))
+		g.tableVersion = v
+	}
+
+	return g.table
+}
+
+// entries flattens the group routes and the ones of its nested groups, in the order they were added.
+// Each entry path includes the group prefix.
+func (g *GroupRoute) entries(routes []Router) (list []entry) {
+	prefix := parseSegments(g.routeParts, false)
+
+	for _, r := range routes {
+		switch r := r.(type) {
+		case *route:
+			e := entry{
+				segs:  joinSegments(prefix, r.segments),
+				chain: []Router{r},
+			}
+			if g.host != nil {
+				e.hosts = [][]segment{g.host}
+			}
+			list = append(list, e)
+
+		case *GroupRoute:
+			for _, e := range r.entries(r.routeList()) {
+				e.segs = joinSegments(prefix, e.segs)
+				e.chain = append(e.chain[:len(e.chain):len(e.chain)], r)
+				if g.host != nil {
+					e.hosts = append(e.hosts[:len(e.hosts):len(e.hosts)], g.host)
+				}
+				list = append(list, e)
+			}
 		}
 	}
 
-	return false
+	return
 }
 
 // Dispatch loops through all routes inside the group and dispatch the one that matches the request.
 // Outside the box, works exactly the same as route.Dispatch().
 func (g *GroupRoute) Dispatch(c *Context) (err error) {
 	if len(c.groupDispatch) == 0 {
-		g.endDispatch(c)
+		endMiddleware(g.middleware, c)
 		return errors.New("No matching route found")
 	}
 
-	// Pre-dispatch middleware
-	for _, m := range g.middleware {
-		// Dispatch
-		err = m.PreDispatch(c)
-		if err != nil {
-			g.endDispatch(c)
-			return
-		}
+	if len(g.wrappers) > 0 {
+		return g.dispatchWrapped(c)
 	}
 
-	// pop, dispatch last route
+	return g.dispatch(c)
+}
+
+// dispatch runs the group middleware around the next Router in the Context dispatch chain.
+func (g *GroupRoute) dispatch(c *Context) error {
+	return dispatchMiddleware(g.middleware, c, g.dispatchNext)
+}
+
+// dispatchNext pops and dispatches the next Router in the Context dispatch chain.
+func (g *GroupRoute) dispatchNext(c *Context) error {
 	n := len(c.groupDispatch) - 1
 	route := c.groupDispatch[n]
 	c.groupDispatch = c.groupDispatch[:n]
-	err = route.Dispatch(c)
-	if err != nil {
-		g.endDispatch(c)
-		return
-	}
 
-	// Post-dispatch middleware
-	for _, m := range g.middleware {
-		// Dispatch
-		err = m.PostDispatch(c)
-		if err != nil {
-			g.endDispatch(c)
-			return
-		}
-	}
+	return route.Dispatch(c)
+}
+
+// Add inserts a new resource with it's associated route into the group object.
+// Optional route settings can be provided, like Named() to build URLs to the route.
+func (g *GroupRoute) Add(url string, h ResourceHandler, opts ...RouteOption) {
+	g.addRoute(Route(url, h, opts...))
+}
+
+// AddGroup inserts a GroupRoute into the routes list of the group object.
+// This makes possible to nest groups.
+func (g *GroupRoute) AddGroup(r *GroupRoute) {
+	r.tableLock.Lock()
+	r.parent = g
+	r.tableLock.Unlock()
 
-	// End dispatch if no errors blocking...
-	g.endDispatch(c)
+	g.addRoute(r)
+}
 
-	// Return success
-	return
+// addRoute appends a Router to the group routes list.
+// Routes can be added while serving requests, as the list is guarded by the table lock.
+func (g *GroupRoute) addRoute(r Router) {
+	g.tableLock.Lock()
+	g.routes = append(g.routes, r)
+	g.tableLock.Unlock()
+
+	g.routesChanged()
+}
+
+// routeList returns the routes added to the group so far.
+func (g *GroupRoute) routeList() []Router {
+	g.tableLock.RLock()
+	defer g.tableLock.RUnlock()
+
+	return g.routes[:len(g.routes):len(g.routes)]
 }
 
-func (g *GroupRoute) endDispatch(c *Context) (err error) {
-	// End dispatch middleware
-	for _, m := range g.middleware {
-		e := m.End(c)
-		if e != nil {
-			// If there are any error, only return the last to be sure we go through all middlewares.
-			err = e
+// Host restricts the group routes to requests for a host name pattern.
+// Patterns are matched by parts, and support params in the same way routes do:
+//
+//	api.example.com
+//	:tenant.example.com
+//	:id<int>.users.example.com
+//
+// Host params are stored into Context.Params along with the route params.
+// Routes in groups with a host pattern have priority over the routes without it,
+// so groups without a host pattern act as fallback for unknown hosts.
+func (g *GroupRoute) Host(pattern string) {
+	host := parseSegments(removeEmpty(strings.Split(pattern, ".")), false)
+	for i := range host {
+		if host[i].kind == staticSegment {
+			host[i].name = strings.ToLower(host[i].name)
 		}
 	}
 
-	return
-}
+	g.tableLock.Lock()
+	g.hostname = pattern
+	g.host = host
+	g.tableLock.Unlock()
 
-// Add inserts a new resource with it's associated route into the group object.
-func (g *GroupRoute) Add(url string, h ResourceHandler) {
-	g.routes = append(g.routes, Route(url, h))
+	g.routesChanged()
 }
 
-// AddGroup inserts a GroupRoute into the routes list of the group object.
-// This makes possible to nest groups.
-func (g *GroupRoute) AddGroup(r *GroupRoute) {
-	g.routes = append(g.routes, r)
+// OnError sets the function handling the errors returned while dispatching a route of the group,
+// overriding Yarf.ErrorHandler. When groups are nested, the innermost group with an error handler wins.
+// It's useful to render errors differently for parts of an app, like HTML pages for an admin group
+// while an API group renders JSON.
+func (g *GroupRoute) OnError(h func(*Context, error)) {
+	g.errorHandler = h
 }
 
 // Insert adds a MiddlewareHandler into the middleware list of the group object.
@@ -249,40 +480,28 @@ func removeEmpty(parts []string) []string {
 	return x
 }
 
-// matches returns true if requestParts matches routeParts up through len(routeParts)
-// ignoring params in routeParts
-func matches(routeParts, requestParts []string) bool {
-	routeCount := len(routeParts)
-
-	// Check for catch-all wildcard
-	if len(routeParts) > 0 && routeParts[len(routeParts)-1] == "*" {
-		routeCount--
+// hostParts splits a host name into its lowercase parts, removing the port if present.
+func hostParts(host string) []string {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
 	}
 
-	if len(requestParts) < routeCount {
-		return false
-	}
+	return removeEmpty(strings.Split(strings.ToLower(host), "."))
+}
 
-	// Check for part matching, ignoring params and * wildcards
-	for i, p := range routeParts {
-		// Skip wildcard
-		if p == "*" {
-			continue
-		}
-		if p != requestParts[i] && p[0] != ':' {
-			return false
-		}
+// requestHost returns the host name parts of a request.
+func requestHost(req *http.Request) []string {
+	if req == nil {
+		return nil
 	}
 
-	return true
+	return hostParts(req.Host)
 }
 
-// storeParams writes parts from requestParts that correspond with param names in
-// routeParts into c.Params.
-func storeParams(c *Context, routeParts, requestParts []string) {
-	for i, p := range routeParts {
-		if p[0] == ':' {
-			c.Params.Set(p[1:], requestParts[i])
-		}
-	}
+// joinSegments returns a new list with the segments of a and b.
+func joinSegments(a, b []segment) []segment {
+	segs := make([]segment, 0, len(a)+len(b))
+	segs = append(segs, a...)
+
+	return append(segs, b...)
 }
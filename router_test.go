@@ -1,7 +1,9 @@
 package yarf
 
 import (
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -597,6 +599,143 @@ func TestRouterNestedGroupMatch(t *testing.T) {
 	}
 }
 
+type MockBodyResource struct {
+	Resource
+}
+
+func (r *MockBodyResource) Get(c *Context) error {
+	c.Response.Header().Set("X-Test", "get")
+	c.Render("body")
+	return nil
+}
+
+func TestRouteAllowHeader(t *testing.T) {
+	y := New()
+	y.Add("/body", new(MockBodyResource))
+
+	req, _ := http.NewRequest("POST", "http://localhost:8080/body", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 405 {
+		t.Errorf("Not implemented method should return 405, %d found", res.Code)
+	}
+	if res.Header().Get("Allow") != "GET, OPTIONS, HEAD" {
+		t.Errorf("Allow header should be 'GET, OPTIONS, HEAD', '%s' found", res.Header().Get("Allow"))
+	}
+}
+
+func TestRouteAllowHeaderWrapped(t *testing.T) {
+	y := New()
+	y.Add("/test", Handlers{"GET": func(c *Context) error {
+		return fmt.Errorf("update: %w", ErrorMethodNotImplemented())
+	}})
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/test", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Header().Get("Allow") != "GET, OPTIONS, HEAD" {
+		t.Errorf("Allow header should be set for wrapped MethodNotImplemented errors, '%s' found", res.Header().Get("Allow"))
+	}
+}
+
+func TestRouteAutomaticOptions(t *testing.T) {
+	y := New()
+	y.Add("/body", new(MockBodyResource))
+
+	req, _ := http.NewRequest("OPTIONS", "http://localhost:8080/body", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 200 {
+		t.Errorf("OPTIONS should return 200, %d found", res.Code)
+	}
+	if res.Header().Get("Allow") != "GET, OPTIONS, HEAD" {
+		t.Errorf("Allow header should be 'GET, OPTIONS, HEAD', '%s' found", res.Header().Get("Allow"))
+	}
+}
+
+func TestRouteAutomaticHead(t *testing.T) {
+	y := New()
+	y.Add("/body", new(MockBodyResource))
+	y.Add("/empty", new(MockResource))
+
+	req, _ := http.NewRequest("HEAD", "http://localhost:8080/body", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 200 {
+		t.Errorf("HEAD should return 200, %d found", res.Code)
+	}
+	if res.Header().Get("X-Test") != "get" {
+		t.Error("HEAD should be served by the GET method")
+	}
+	if res.Body.Len() != 0 {
+		t.Errorf("HEAD response body should be empty, '%s' found", res.Body.String())
+	}
+
+	req, _ = http.NewRequest("HEAD", "http://localhost:8080/empty", nil)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 405 {
+		t.Errorf("HEAD without GET should return 405, %d found", res.Code)
+	}
+	if res.Header().Get("Allow") != "OPTIONS" {
+		t.Errorf("Allow header should be 'OPTIONS', '%s' found", res.Header().Get("Allow"))
+	}
+}
+
+type MockNameResource struct {
+	Resource
+	name string
+}
+
+func (r *MockNameResource) Get(c *Context) error {
+	c.Render(r.name + ":" + c.Param("tenant"))
+	return nil
+}
+
+func TestRouteGroupHost(t *testing.T) {
+	y := New()
+
+	// Fallback route added first, host routes have priority anyway.
+	y.Add("/", &MockNameResource{name: "default"})
+
+	api := RouteGroup("")
+	api.Host("API.example.com")
+	api.Add("/", &MockNameResource{name: "api"})
+	y.AddGroup(api)
+
+	tenants := RouteGroup("/")
+	tenants.Host(":tenant.example.com")
+	tenants.Add("/", &MockNameResource{name: "tenant"})
+	y.AddGroup(tenants)
+
+	tests := map[string]string{
+		"api.example.com":      "api:",
+		"api.example.com:8080": "api:",
+		"acme.example.com":     "tenant:acme",
+		"other.example.com":    "tenant:other",
+		"example.com":          "default:",
+		"a.b.example.com":      "default:",
+	}
+
+	// Run twice to check cached routes
+	for i := 0; i < 2; i++ {
+		for host, body := range tests {
+			req, _ := http.NewRequest("GET", "http://"+host+"/", nil)
+			res := httptest.NewRecorder()
+			y.ServeHTTP(res, req)
+
+			if res.Body.String() != body {
+				t.Errorf("Request to '%s' should render '%s', '%s' found", host, body, res.Body.String())
+			}
+		}
+	}
+}
+
 func BenchmarkRouteMatch_short(b *testing.B) {
 	h := &Handler{}
 	c := &Context{}
@@ -0,0 +1,107 @@
+package yarf
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a route registered into a group.
+type RouteInfo struct {
+	Path       string   // Full route path, including group prefixes.
+	Name       string   // Route name, if set.
+	Host       string   // Host pattern the route is restricted to, if any.
+	Groups     []string // Group prefixes containing the route, from outer to inner.
+	Middleware []string // Middleware types running for the route, in dispatch order.
+	Resource   string   // ResourceHandler type.
+	Methods    []string // HTTP methods implemented by the resource.
+}
+
+// Routes returns the information of all routes inside the group, including the ones in nested groups,
+// in the order they were added.
+func (g *GroupRoute) Routes() []RouteInfo {
+	return g.walk("", nil, nil, nil)
+}
+
+// walk collects the route information of the group,
+// using the host, prefixes and middleware of the parent groups.
+func (g *GroupRoute) walk(host string, parts, groups, middleware []string) (list []RouteInfo) {
+	if g.hostname != "" {
+		host = g.hostname
+	}
+	parts = append(parts[:len(parts):len(parts)], g.routeParts...)
+	groups = append(groups[:len(groups):len(groups)], g.prefix)
+	for _, m := range g.middleware {
+		middleware = append(middleware[:len(middleware):len(middleware)], fmt.Sprintf("%T", m))
+	}
+
+	for _, r := range g.routeList() {
+		switch r := r.(type) {
+		case *route:
+			var resource interface{} = r.handler
+			if r.mount != nil {
+				resource = r.mount
+			}
+
+			mw := middleware
+			for _, m := range r.middleware {
+				mw = append(mw[:len(mw):len(mw)], fmt.Sprintf("%T", m))
+			}
+
+			list = append(list, RouteInfo{
+				Path:       "/" + strings.Join(append(parts[:len(parts):len(parts)], r.routeParts...), "/"),
+				Name:       r.name,
+				Host:       host,
+				Groups:     groups,
+				Middleware: mw,
+				Resource:   fmt.Sprintf("%T", resource),
+				Methods:    r.methods,
+			})
+
+		case *GroupRoute:
+			list = append(list, r.walk(host, parts, groups, middleware)...)
+		}
+	}
+
+	return
+}
+
+// RoutesResource renders the routes table of the Yarf server handling the request.
+// It's intended for debugging and auditing purposes, so it should be protected or disabled on production.
+// The table is rendered as JSON, unless text format is requested using the "format=text" query param.
+//
+//	y.Add("/debug/routes", new(yarf.RoutesResource))
+type RoutesResource struct {
+	Resource
+}
+
+// Get renders the routes table.
+func (r *RoutesResource) Get(c *Context) error {
+	if c.yarf == nil {
+		return ErrorNotFound()
+	}
+
+	routes := c.yarf.Routes()
+
+	if c.QueryValue("format") != "text" {
+		return c.RenderJSONIndent(routes)
+	}
+
+	c.Response.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	w := tabwriter.NewWriter(c.Response, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tNAME\tMETHODS\tRESOURCE\tMIDDLEWARE")
+	for _, route := range routes {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\n",
+			route.Path,
+			route.Name,
+			strings.Join(route.Methods, ","),
+			route.Resource,
+			strings.Join(route.Middleware, ","),
+		)
+	}
+
+	return w.Flush()
+}
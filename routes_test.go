@@ -0,0 +1,68 @@
+package yarf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	y := New()
+	y.Insert(new(MockMiddleware))
+	y.Add("/", new(MockGetResource), Named("home"))
+
+	g := RouteGroup("/v1/")
+	g.Add("/users/:id<int>", new(MockGetPostResource))
+	y.AddGroup(g)
+
+	routes := y.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("2 routes added, %d found", len(routes))
+	}
+
+	if routes[0].Path != "/" || routes[0].Name != "home" {
+		t.Errorf("First route should be '/' named 'home', found %+v", routes[0])
+	}
+	if routes[1].Path != "/v1/users/:id<int>" {
+		t.Errorf("Second route path should be '/v1/users/:id<int>', '%s' found", routes[1].Path)
+	}
+	if strings.Join(routes[1].Groups, ",") != ",/v1/" {
+		t.Errorf("Second route groups should be '' and '/v1/', %v found", routes[1].Groups)
+	}
+	if strings.Join(routes[1].Middleware, ",") != "*yarf.MockMiddleware" {
+		t.Errorf("Second route middleware should be *yarf.MockMiddleware, %v found", routes[1].Middleware)
+	}
+	if routes[1].Resource != "*yarf.MockGetPostResource" {
+		t.Errorf("Second route resource should be *yarf.MockGetPostResource, '%s' found", routes[1].Resource)
+	}
+	if strings.Join(routes[1].Methods, ",") != "GET,POST" {
+		t.Errorf("Second route methods should be GET and POST, %v found", routes[1].Methods)
+	}
+}
+
+func TestRoutesResource(t *testing.T) {
+	y := New()
+	y.Add("/debug/routes", new(RoutesResource))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/debug/routes", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	var routes []RouteInfo
+	if err := json.Unmarshal(res.Body.Bytes(), &routes); err != nil {
+		t.Fatalf("Routes JSON should be valid: %s", err)
+	}
+	if len(routes) != 1 || routes[0].Path != "/debug/routes" {
+		t.Errorf("Routes JSON should list '/debug/routes', %+v found", routes)
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/debug/routes?format=text", nil)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if !strings.HasPrefix(res.Body.String(), "PATH") || !strings.Contains(res.Body.String(), "/debug/routes") {
+		t.Errorf("Routes text table should list '/debug/routes', '%s' found", res.Body.String())
+	}
+}
@@ -0,0 +1,433 @@
+package yarf
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// routesChanged increases the routes version of the group and the groups containing it.
+// Compiled route trees store the version they were built from,
+// so they get rebuilt when routes are added after the first request.
+// The root group version also tells Yarf when to clear its route cache,
+// so changes on an app never affect the others.
+func (g *GroupRoute) routesChanged() {
+	for p := g; p != nil; p = p.parentGroup() {
+		atomic.AddUint64(&p.version, 1)
+	}
+}
+
+// routesVersion returns the current routes version of the group.
+func (g *GroupRoute) routesVersion() uint64 {
+	return atomic.LoadUint64(&g.version)
+}
+
+// parentGroup returns the group containing g, if any.
+func (g *GroupRoute) parentGroup() *GroupRoute {
+	g.tableLock.RLock()
+	defer g.tableLock.RUnlock()
+
+	return g.parent
+}
+
+// Segment kinds
+const (
+	staticSegment   = iota // Literal match: /users
+	paramSegment           // Single part match: /:id or /*
+	catchAllSegment        // Trailing wildcard: /* or /*name
+)
+
+// segment is a parsed route part.
+type segment struct {
+	kind     int
+	name     string    // Literal text for static segments, param name for params.
+	optional bool      // Optional params can be missing from the request path
+	spec     string    // Param type constraint as written in the route: int, uuid, regex:[a-z]+
+	check    ParamType // Param type constraint validation
+}
+
+// parseSegments converts route parts into segments.
+// When tail is true, a trailing * wildcard is parsed as a catch-all segment.
+// Otherwise it matches a single part, as any other wildcard.
+// Named wildcards (*name) store the matched value as a param.
+func parseSegments(parts []string, tail bool) []segment {
+	segs := make([]segment, 0, len(parts))
+
+	for i, p := range parts {
+		switch {
+		case p[0] == '*' && tail && i == len(parts)-1:
+			segs = append(segs, segment{kind: catchAllSegment, name: p[1:]})
+
+		case p[0] == '*':
+			segs = append(segs, segment{kind: paramSegment, name: p[1:]})
+
+		case p[0] == ':':
+			segs = append(segs, parseParam(p[1:]))
+
+		default:
+			segs = append(segs, segment{kind: staticSegment, name: p})
+		}
+	}
+
+	return segs
+}
+
+// parseParam parses a param definition in the form name<type>.
+// A trailing ? makes the param optional: name? or name<type>?
+// It panics if the type constraint isn't valid, as routes are defined on startup.
+func parseParam(p string) segment {
+	s := segment{kind: paramSegment}
+
+	if strings.HasSuffix(p, "?") {
+		s.optional = true
+		p = p[:len(p)-1]
+	}
+	s.name = p
+
+	i := strings.IndexByte(p, '<')
+	if i < 0 || !strings.HasSuffix(p, ">") {
+		return s
+	}
+
+	s.name = p[:i]
+	s.spec = p[i+1 : len(p)-1]
+
+	check, err := paramTypeFor(s.spec)
+	if err != nil {
+		panic(fmt.Sprintf("yarf: invalid route param ':%s': %s", p, err))
+	}
+	s.check = check
+
+	return s
+}
+
+// expandOptional returns all the segment combinations for a route with optional params,
+// from the longest to the shortest one.
+// Routes without optional params return a single combination.
+func expandOptional(segs []segment) [][]segment {
+	list := [][]segment{nil}
+
+	for _, s := range segs {
+		n := len(list)
+		for i := 0; i < n; i++ {
+			if s.optional {
+				list = append(list, list[i])
+			}
+			list[i] = joinSegments(list[i], []segment{s})
+		}
+	}
+
+	return list
+}
+
+// leaf is a route stored into the tree.
+type leaf struct {
+	order int         // Registration order. Lower orders have priority.
+	chain []Router    // Routers to be dispatched, in Context.groupDispatch order.
+	names []string    // Param names for every non-static segment. Empty for wildcards.
+	hosts [][]segment // Host patterns the request has to match
+	tail  bool        // The route ends with a catch-all wildcard
+}
+
+// paramValue is a param captured during a lookup.
+type paramValue struct {
+	value string      // Raw value from the request path
+	typed interface{} // Value converted by the param type, if any
+}
+
+// node is a single part level of the route tree.
+// Static children are indexed by their literal value,
+// so the lookup cost depends on the request path length instead of the amount of routes.
+type node struct {
+	static   map[string]*node // Literal children
+	params   []*node          // Param and wildcard children, one for each type constraint
+	spec     string           // Type constraint for param nodes
+	check    ParamType        // Type constraint validation for param nodes
+	leaves   []*leaf          // Routes ending at this node
+	catchAll []*leaf          // Catch-all routes starting at this node
+	min      int              // Lowest leaf order on this subtree, used to prune lookups.
+}
+
+// newNode creates an empty node.
+func newNode() *node {
+	return &node{min: -1}
+}
+
+// insert adds a leaf to the tree following the provided segments.
+func (n *node) insert(segs []segment, l *leaf) {
+	for _, s := range segs {
+		n.visit(l.order)
+
+		switch s.kind {
+		case staticSegment:
+			if n.static == nil {
+				n.static = make(map[string]*node)
+			}
+			child, ok := n.static[s.name]
+			if !ok {
+				child = newNode()
+				n.static[s.name] = child
+			}
+			n = child
+
+		case paramSegment:
+			n = n.param(s)
+
+		case catchAllSegment:
+			n.catchAll = append(n.catchAll, l)
+			return
+		}
+	}
+
+	n.visit(l.order)
+	n.leaves = append(n.leaves, l)
+}
+
+// param returns the param child for the segment type constraint, creating it if needed.
+func (n *node) param(s segment) *node {
+	for _, child := range n.params {
+		if child.spec == s.spec {
+			return child
+		}
+	}
+
+	child := newNode()
+	child.spec = s.spec
+	child.check = s.check
+	n.params = append(n.params, child)
+
+	return child
+}
+
+// visit updates the node min order with a new leaf order.
+func (n *node) visit(order int) {
+	if n.min < 0 || order < n.min {
+		n.min = order
+	}
+}
+
+// match is the result of a tree lookup.
+type match struct {
+	leaf        *leaf
+	values      []paramValue  // Values for every leaf name.
+	req         *http.Request // Request being matched
+	host        []string      // Request host name parts, parsed when needed
+	hostParams  Params        // Params from the leaf host patterns
+	conditional bool          // Routes with request matchers were checked
+}
+
+// better returns true if a leaf with the given order would be preferred over the current match.
+func (m *match) better(order int) bool {
+	return m.leaf == nil || order < m.leaf.order
+}
+
+// try stores a leaf as the current match if the request matches its host patterns and route matchers.
+func (m *match) try(l *leaf, values []paramValue) bool {
+	var params Params
+	if len(l.hosts) > 0 && m.host == nil {
+		m.host = requestHost(m.req)
+	}
+	for _, h := range l.hosts {
+		if !matchHost(h, m.host, &params) {
+			return false
+		}
+	}
+
+	if r := l.chain[0].(*route); len(r.matchers) > 0 {
+		m.conditional = true
+		if !r.accepts(m.req) {
+			return false
+		}
+	}
+
+	m.leaf = l
+	m.values = append(m.values[:0], values...)
+	m.hostParams = params
+
+	return true
+}
+
+// matchHost checks the request host parts against a host pattern, storing its params.
+func matchHost(pattern []segment, host []string, params *Params) bool {
+	if len(pattern) != len(host) {
+		return false
+	}
+
+	for i, s := range pattern {
+		if s.kind == staticSegment {
+			if s.name != host[i] {
+				return false
+			}
+			continue
+		}
+
+		var typed interface{}
+		if s.check != nil {
+			v, ok := s.check(host[i])
+			if !ok {
+				return false
+			}
+			typed = v
+		}
+		if s.name != "" {
+			params.set(s.name, host[i], typed)
+		}
+	}
+
+	return true
+}
+
+// lookup finds the first registered route matching the request parts.
+// The tree is walked in depth, but branches that can't improve the current match are skipped.
+func (n *node) lookup(parts []string, values []paramValue, m *match) {
+	if !m.better(n.min) {
+		return
+	}
+
+	// Leaves are sorted by order, the first one matching the host wins.
+	if len(parts) == 0 {
+		for _, l := range n.leaves {
+			if !m.better(l.order) || m.try(l, values) {
+				break
+			}
+		}
+	}
+
+	for _, l := range n.catchAll {
+		if !m.better(l.order) || m.try(l, append(values, paramValue{value: strings.Join(parts, "/")})) {
+			break
+		}
+	}
+
+	if len(parts) == 0 {
+		return
+	}
+
+	if child, ok := n.static[parts[0]]; ok {
+		child.lookup(parts[1:], values, m)
+	}
+
+	// Params not passing their type constraint fall through to the next candidates.
+	for _, child := range n.params {
+		v := paramValue{value: parts[0]}
+		if child.check != nil {
+			typed, ok := child.check(parts[0])
+			if !ok {
+				continue
+			}
+			v.typed = typed
+		}
+
+		child.lookup(parts[1:], append(values, v), m)
+	}
+}
+
+// find looks for the route matching the request parts.
+// The request is used to check host patterns and route matchers, if any.
+func (n *node) find(parts []string, req *http.Request) (m match) {
+	m.req = req
+	n.lookup(parts, make([]paramValue, 0, len(parts)+1), &m)
+	return
+}
+
+// store writes the matched route params into the Context.
+func (m *match) store(c *Context) {
+	for i, name := range m.leaf.names {
+		if name != "" {
+			c.Params.set(name, m.values[i].value, m.values[i].typed)
+		}
+	}
+
+	for _, p := range m.hostParams {
+		c.Params.set(p.Key, p.Value, p.typed)
+	}
+
+	if m.leaf.tail {
+		c.tail = m.values[len(m.values)-1].value
+	}
+
+	if m.conditional {
+		c.uncacheable = true
+	}
+}
+
+// entry is a flattened route definition used to build trees.
+type entry struct {
+	segs  []segment
+	chain []Router
+	hosts [][]segment
+}
+
+// leaf creates the tree leaf for an entry path.
+func (e entry) leaf(order int, segs []segment) *leaf {
+	l := &leaf{
+		order: order,
+		chain: e.chain[:len(e.chain):len(e.chain)],
+		hosts: e.hosts,
+		tail:  len(segs) > 0 && segs[len(segs)-1].kind == catchAllSegment,
+	}
+
+	for _, s := range segs {
+		if s.kind != staticSegment {
+			l.names = append(l.names, s.name)
+		}
+	}
+
+	return l
+}
+
+// buildTree compiles a list of entries into a route tree.
+// Entries keep their list position as priority,
+// but routes restricted to host patterns go before the ones that aren't.
+// Routes with optional params are inserted once for every possible path.
+func buildTree(entries []entry) *node {
+	root := newNode()
+
+	sorted := make([]entry, 0, len(entries))
+	for _, e := range entries {
+		if len(e.hosts) > 0 {
+			sorted = append(sorted, e)
+		}
+	}
+	for _, e := range entries {
+		if len(e.hosts) == 0 {
+			sorted = append(sorted, e)
+		}
+	}
+
+	for i, e := range sorted {
+		for _, segs := range expandOptional(e.segs) {
+			root.insert(segs, e.leaf(i, segs))
+		}
+	}
+
+	return root
+}
+
+// routeTable is the compiled form of the routes in a group.
+type routeTable struct {
+	tree  *node            // Routes tree
+	names map[string]entry // Named routes. The first one added wins.
+	hosts bool             // Some routes are restricted to host patterns
+}
+
+// newRouteTable compiles a list of entries.
+func newRouteTable(entries []entry) *routeTable {
+	t := &routeTable{
+		tree:  buildTree(entries),
+		names: make(map[string]entry),
+	}
+
+	for _, e := range entries {
+		r := e.chain[0].(*route)
+		if _, ok := t.names[r.name]; r.name != "" && !ok {
+			t.names[r.name] = e
+		}
+		if len(e.hosts) > 0 {
+			t.hosts = true
+		}
+	}
+
+	return t
+}
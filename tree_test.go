@@ -0,0 +1,348 @@
+package yarf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTreeMatchPriority(t *testing.T) {
+	first := new(Handler)
+	second := new(Handler)
+
+	g := RouteGroup("")
+	g.Add("/users/:id", first)
+	g.Add("/users/new", second)
+
+	c := &Context{Params: Params{}}
+	if !g.Match("/users/new", c) {
+		t.Fatal("'/users/new' should match")
+	}
+	if c.groupDispatch[0].(*route).handler != first {
+		t.Error("First added route should have priority over later static routes")
+	}
+	if c.Param("id") != "new" {
+		t.Errorf("Param 'id' should be 'new', '%s' found", c.Param("id"))
+	}
+}
+
+func TestTreeMatchBacktracking(t *testing.T) {
+	h := new(Handler)
+
+	g := RouteGroup("")
+	g.Add("/a/b/c", h)
+	g.Add("/a/:param/d", h)
+
+	c := &Context{Params: Params{}}
+	if !g.Match("/a/b/d", c) {
+		t.Fatal("'/a/b/d' should match against '/a/:param/d'")
+	}
+	if c.Param("param") != "b" {
+		t.Errorf("Param 'param' should be 'b', '%s' found", c.Param("param"))
+	}
+}
+
+func TestTreeNestedGroupChain(t *testing.T) {
+	h := new(Handler)
+
+	l1 := RouteGroup("/level1/:one")
+	l2 := RouteGroup("/level2")
+	l2.Add("/test/:two", h)
+	l1.AddGroup(l2)
+
+	c := &Context{Params: Params{}}
+	if !l1.Match("/level1/1/level2/test/2", c) {
+		t.Fatal("Nested route should match")
+	}
+	if len(c.groupDispatch) != 2 {
+		t.Fatalf("Dispatch chain should have 2 routers, %d found", len(c.groupDispatch))
+	}
+	if _, ok := c.groupDispatch[0].(*route); !ok {
+		t.Error("First router on the chain should be the route")
+	}
+	if c.groupDispatch[1] != l2 {
+		t.Error("Last router on the chain should be the nested group")
+	}
+	if c.Param("one") != "1" || c.Param("two") != "2" {
+		t.Errorf("Params from group and route should be stored: %v", c.Params)
+	}
+}
+
+func TestTreeRoutesAddedAfterMatch(t *testing.T) {
+	h := new(Handler)
+
+	g := RouteGroup("/v1")
+	n := RouteGroup("/nested")
+	g.AddGroup(n)
+
+	c := &Context{Params: Params{}}
+	if g.Match("/v1/nested/test", c) {
+		t.Fatal("Route shouldn't match before it's added")
+	}
+
+	n.Add("/test", h)
+
+	if !g.Match("/v1/nested/test", c) {
+		t.Error("Route added to a nested group after the first match should match")
+	}
+}
+
+func TestTreeOptionalParams(t *testing.T) {
+	r := Route("/posts/:id?/comments/:page<int>?", new(Handler))
+
+	tests := map[string]map[string]string{
+		"/posts/comments":        {},
+		"/posts/1/comments":      {"id": "1"},
+		"/posts/1/comments/2":    {"id": "1", "page": "2"},
+		"/posts/comments/2":      {"page": "2"},
+		"/posts/a/b/comments/2/": nil,
+		"/posts/1/comments/two":  nil,
+	}
+
+	for url, params := range tests {
+		c := &Context{Params: Params{}}
+		matched := r.Match(url, c)
+
+		if params == nil {
+			if matched {
+				t.Errorf("'%s' shouldn't match", url)
+			}
+			continue
+		}
+		if !matched {
+			t.Errorf("'%s' should match", url)
+			continue
+		}
+		if len(c.Params) != len(params) {
+			t.Errorf("'%s' should store params %v, %v found", url, params, c.Params)
+		}
+		for k, v := range params {
+			if c.Param(k) != v {
+				t.Errorf("'%s' should store param %s=%s, '%s' found", url, k, v, c.Param(k))
+			}
+		}
+	}
+}
+
+func TestTreeNamedCatchAll(t *testing.T) {
+	g := RouteGroup("/static")
+	g.Add("/:dir/*filepath", new(Handler))
+
+	c := &Context{Params: Params{}}
+	if !g.Match("/static/css/vendor//theme/main.css", c) {
+		t.Fatal("Catch-all route should match")
+	}
+	if c.Param("dir") != "css" {
+		t.Errorf("Param 'dir' should be 'css', '%s' found", c.Param("dir"))
+	}
+	if c.Param("filepath") != "vendor/theme/main.css" {
+		t.Errorf("Param 'filepath' should be 'vendor/theme/main.css', '%s' found", c.Param("filepath"))
+	}
+
+	c = &Context{Params: Params{}}
+	if !g.Match("/static/css", c) {
+		t.Fatal("Catch-all route should match an empty remainder")
+	}
+	if len(c.Params) != 2 || c.Param("filepath") != "" {
+		t.Errorf("Param 'filepath' should be stored empty, %v found", c.Params)
+	}
+}
+
+// benchTables are the route tables of the router tests, with requests matching each route.
+var benchTables = []struct {
+	groups   []string
+	route    string
+	requests []string
+}{
+	{nil, "/", []string{"/"}},
+	{nil, "/*", []string{"/something", "/something/else/more"}},
+	{nil, "/level", []string{"/level"}},
+	{nil, "/level/*", []string{"/level/something", "/level/something/else/and/more/because/this/matches/all"}},
+	{nil, "/a/b/c", []string{"/a/b/c", "/a/b/c/"}},
+	{nil, "/a/b/*/d", []string{"/a/b/c/d", "/a/b/something/d"}},
+	{nil, "/a/b/*", []string{"/a/b/c/d/e", "/a/b/c/d"}},
+	{nil, "/:param", []string{"/cafewafewa", "/:paramStyle"}},
+	{nil, "/:param/*", []string{"/something/more/to/catch"}},
+	{nil, "/a/b/:param", []string{"/a/b/c", "/a/b/:param"}},
+	{nil, "/a/*/:param", []string{"/a/b/c"}},
+	{nil, "/a/b/:param/*", []string{"/a/b/c/d/e/f/g", "/a/b/c/d/:param/*"}},
+	{[]string{"/v1"}, "/test/:param", []string{"/v1/test/test"}},
+	{[]string{"/v1"}, "/test/*", []string{"/v1/test/this/is/a/wild/card"}},
+	{[]string{"/test/:param/"}, "/blah", []string{"/test/param/blah"}},
+	{[]string{"/level1", "/level2", "/level3"}, "/test/:param", []string{"/level1/level2/level3/test/test"}},
+}
+
+// linearRoute and linearGroup are a copy of the router before the route tree, trying every route in order.
+// They're the baseline of the route table benchmarks.
+type linearRoute struct {
+	routeParts []string
+}
+
+func (r *linearRoute) Match(url string, c *Context) bool {
+	requestParts := prepareURL(url)
+
+	if len(r.routeParts) == 0 || (len(r.routeParts) > 0 && r.routeParts[len(r.routeParts)-1] != "*") {
+		if len(r.routeParts) != len(requestParts) {
+			return false
+		}
+	}
+
+	if !linearMatches(r.routeParts, requestParts) {
+		return false
+	}
+
+	linearStoreParams(c, r.routeParts, requestParts)
+
+	return true
+}
+
+type linearGroup struct {
+	routeParts []string
+	routes     []interface {
+		Match(string, *Context) bool
+	}
+}
+
+func (g *linearGroup) Match(url string, c *Context) bool {
+	urlParts := prepareURL(url)
+
+	if !linearMatches(g.routeParts, urlParts) {
+		return false
+	}
+
+	rURL := strings.Join(urlParts[len(g.routeParts):], "/")
+
+	for _, r := range g.routes {
+		if r.Match(rURL, c) {
+			c.groupDispatch = append(c.groupDispatch, nil)
+			linearStoreParams(c, g.routeParts, urlParts)
+			return true
+		}
+	}
+
+	return false
+}
+
+func linearMatches(routeParts, requestParts []string) bool {
+	routeCount := len(routeParts)
+
+	if len(routeParts) > 0 && routeParts[len(routeParts)-1] == "*" {
+		routeCount--
+	}
+
+	if len(requestParts) < routeCount {
+		return false
+	}
+
+	for i, p := range routeParts {
+		if p == "*" {
+			continue
+		}
+		if p != requestParts[i] && p[0] != ':' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func linearStoreParams(c *Context, routeParts, requestParts []string) {
+	for i, p := range routeParts {
+		if p[0] == ':' {
+			c.Params.Set(p[1:], requestParts[i])
+		}
+	}
+}
+
+// benchRouters builds the router tests tables into the route tree and the linear router.
+// Each copy of the tables is added under its own group, so n copies make a table of n*16 routes.
+func benchRouters(b *testing.B, n int) (*GroupRoute, *linearGroup, []string) {
+	h := new(Handler)
+	tree := RouteGroup("")
+	linear := &linearGroup{}
+	var paths []string
+
+	for i := 0; i < n; i++ {
+		for j, table := range benchTables {
+			prefix := fmt.Sprintf("/t%d-%d", i, j)
+
+			g := RouteGroup(prefix)
+			lg := &linearGroup{routeParts: prepareURL(prefix)}
+			tree.AddGroup(g)
+			linear.routes = append(linear.routes, lg)
+
+			for _, group := range table.groups {
+				sub := RouteGroup(group)
+				lsub := &linearGroup{routeParts: prepareURL(group)}
+				g.AddGroup(sub)
+				lg.routes = append(lg.routes, lsub)
+				g, lg = sub, lsub
+			}
+
+			g.Add(table.route, h)
+			lg.routes = append(lg.routes, &linearRoute{routeParts: prepareURL(table.route)})
+
+			for _, r := range table.requests {
+				paths = append(paths, prefix+r)
+			}
+		}
+	}
+
+	c := &Context{Params: Params{}}
+	for _, p := range paths {
+		if !tree.Match(p, c) || !linear.Match(p, c) {
+			b.Fatalf("'%s' should match on both routers", p)
+		}
+	}
+
+	return tree, linear, paths
+}
+
+func benchmarkLinear(b *testing.B, n int) {
+	_, linear, paths := benchRouters(b, n)
+	c := &Context{Params: Params{}}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c.Params = c.Params[:0]
+		c.groupDispatch = c.groupDispatch[:0]
+		linear.Match(paths[i%len(paths)], c)
+	}
+}
+
+func benchmarkTree(b *testing.B, n int) {
+	tree, _, paths := benchRouters(b, n)
+	c := &Context{Params: Params{}}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c.Params = c.Params[:0]
+		c.groupDispatch = c.groupDispatch[:0]
+		tree.Match(paths[i%len(paths)], c)
+	}
+}
+
+func BenchmarkRouteTable_linear(b *testing.B)    { benchmarkLinear(b, 1) }
+func BenchmarkRouteTable_tree(b *testing.B)      { benchmarkTree(b, 1) }
+func BenchmarkRouteTable_linear600(b *testing.B) { benchmarkLinear(b, 38) }
+func BenchmarkRouteTable_tree600(b *testing.B)   { benchmarkTree(b, 38) }
+
+func BenchmarkRouteTable_linearNotFound(b *testing.B) {
+	_, linear, _ := benchRouters(b, 38)
+	c := &Context{Params: Params{}}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		linear.Match("/this/route/does/not/exist", c)
+	}
+}
+
+func BenchmarkRouteTable_treeNotFound(b *testing.B) {
+	tree, _, _ := benchRouters(b, 38)
+	c := &Context{Params: Params{}}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tree.Match("/this/route/does/not/exist", c)
+	}
+}
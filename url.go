@@ -0,0 +1,78 @@
+package yarf
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// URL builds the path to a named route, including the prefixes of the groups containing it.
+// Route params are provided as key/value pairs:
+//
+//	y.Add("/users/:id<int>", new(User), yarf.Named("user"))
+//	y.URL("user", "id", "42") // "/users/42"
+//
+// It returns an error if the route doesn't exist,
+// or if a required param is missing or doesn't satisfy the param type.
+// When more than one route has the same name, the first one added is used.
+func (g *GroupRoute) URL(name string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", errors.New("yarf: URL params must be provided as key/value pairs")
+	}
+
+	e, ok := g.compile().names[name]
+	if !ok {
+		return "", errors.New("yarf: route '" + name + "' not found")
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	return buildURL(e.segs, values)
+}
+
+// buildURL replaces the params in a route path with the values provided.
+func buildURL(segs []segment, values map[string]string) (string, error) {
+	parts := make([]string, 0, len(segs))
+
+	for _, s := range segs {
+		if s.kind == staticSegment {
+			parts = append(parts, s.name)
+			continue
+		}
+
+		v, ok := values[s.name]
+		if !ok || s.name == "" {
+			if s.optional || s.kind == catchAllSegment {
+				continue
+			}
+			if s.name == "" {
+				return "", errors.New("yarf: can't build URL for routes with unnamed wildcards")
+			}
+
+			return "", errors.New("yarf: missing URL param '" + s.name + "'")
+		}
+
+		if s.kind == catchAllSegment {
+			for _, p := range prepareURL(v) {
+				parts = append(parts, url.PathEscape(p))
+			}
+			continue
+		}
+
+		if v == "" {
+			return "", errors.New("yarf: empty URL param '" + s.name + "'")
+		}
+		if s.check != nil {
+			if _, ok := s.check(v); !ok {
+				return "", errors.New("yarf: invalid URL param '" + s.name + "', expected " + s.spec)
+			}
+		}
+
+		parts = append(parts, url.PathEscape(v))
+	}
+
+	return "/" + strings.Join(parts, "/"), nil
+}
@@ -0,0 +1,85 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	y := New()
+	y.Add("/", new(Handler), Named("home"))
+
+	g := RouteGroup("/v1/:tenant")
+	g.Add("/users/:id<int>", new(Handler), Named("user"))
+	g.Add("/posts/:id?/comments", new(Handler), Named("comments"))
+	g.Add("/files/*path", new(Handler), Named("files"))
+	g.Add("/any/*/thing", new(Handler), Named("wildcard"))
+	y.AddGroup(g)
+
+	tests := []struct {
+		name   string
+		params []string
+		url    string
+	}{
+		{"home", nil, "/"},
+		{"user", []string{"tenant", "acme", "id", "42"}, "/v1/acme/users/42"},
+		{"comments", []string{"tenant", "acme"}, "/v1/acme/posts/comments"},
+		{"comments", []string{"tenant", "acme", "id", "7"}, "/v1/acme/posts/7/comments"},
+		{"files", []string{"tenant", "a b", "path", "css/main.css"}, "/v1/a%20b/files/css/main.css"},
+	}
+
+	for _, test := range tests {
+		url, err := y.URL(test.name, test.params...)
+		if err != nil {
+			t.Errorf("URL(%s, %v) returned error: %s", test.name, test.params, err)
+		}
+		if url != test.url {
+			t.Errorf("URL(%s, %v) should return '%s', '%s' found", test.name, test.params, test.url, url)
+		}
+	}
+
+	errs := []struct {
+		name   string
+		params []string
+	}{
+		{"unknown", nil},
+		{"user", []string{"tenant", "acme"}},
+		{"user", []string{"tenant", "acme", "id", "joe"}},
+		{"user", []string{"tenant"}},
+		{"wildcard", []string{"tenant", "acme"}},
+	}
+
+	for _, test := range errs {
+		if _, err := y.URL(test.name, test.params...); err == nil {
+			t.Errorf("URL(%s, %v) should return an error", test.name, test.params)
+		}
+	}
+}
+
+type MockURLResource struct {
+	Resource
+}
+
+func (r *MockURLResource) Get(c *Context) error {
+	url, err := c.URLFor("self", "id", c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	c.Render(url)
+	return nil
+}
+
+func TestContextURLFor(t *testing.T) {
+	y := New()
+	y.Add("/self/:id", new(MockURLResource), Named("self"))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/self/1", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Body.String() != "/self/1" {
+		t.Errorf("URLFor() should render '/self/1', '%s' found", res.Body.String())
+	}
+}
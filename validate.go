@@ -0,0 +1,434 @@
+package yarf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError is a validation failure of a single struct field.
+type FieldError struct {
+	Field   string `json:"field"`   // Field path, using the json tag names: address.city, items[0].name
+	Rule    string `json:"rule"`    // Rule failed, as in the validate tag: required, min=3
+	Message string `json:"message"` // Readable description of the failure
+}
+
+// ValidationError is returned by Validate when some struct fields aren't valid.
+// It's a 422 YError whose body is the JSON encoded list of field errors.
+type ValidationError struct {
+	CustomError
+
+	Fields []FieldError
+}
+
+// ErrorValidation creates ValidationError
+func ErrorValidation(fields ...FieldError) *ValidationError {
+	e := new(ValidationError)
+	e.HTTPCode = http.StatusUnprocessableEntity
+	e.ErrorCode = 5
+	e.ErrorMsg = "Validation failed"
+	e.Fields = fields
+
+	return e
+}
+
+// Error returns the error message along with all the field errors.
+func (e *ValidationError) Error() string {
+	list := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		list[i] = f.Field + " " + f.Message
+	}
+
+	return e.ErrorMsg + ": " + strings.Join(list, ", ")
+}
+
+// Body returns the field errors encoded as JSON: {"errors":[{"field":"name","rule":"required","message":"is required"}]}
+func (e *ValidationError) Body() string {
+	body, _ := json.Marshal(struct {
+		Errors []FieldError `json:"errors"`
+	}{e.Fields})
+
+	return string(body)
+}
+
+// Validate checks the struct pointed by v against the rules defined on its validate struct tags.
+// Rules are separated by commas:
+//   - required		// The field can't be empty. Other rules are skipped for empty fields that aren't required.
+//   - min=n		// Minimum value for numbers, or minimum length for strings, slices and maps.
+//   - max=n		// Maximum value for numbers, or maximum length for strings, slices and maps.
+//   - len=n		// Exact length for strings, slices and maps.
+//   - oneof=a b c	// The value is one of the space separated list.
+//   - email		// The string is an email address.
+//   - regex=expr	// The string matches the full regular expression. It has to be the last rule.
+//
+// Pointer fields are only empty when nil, so required accepts pointers to zero values like 0 or false,
+// and the other rules check the value pointed to.
+// Nested structs, and slices of structs, are validated as well.
+//
+//	type NewUser struct {
+//		Name  string   `json:"name" validate:"required,max=50"`
+//		Email string   `json:"email" validate:"required,email"`
+//		Role  string   `json:"role" validate:"oneof=admin user"`
+//		Tags  []string `json:"tags" validate:"max=5"`
+//	}
+//
+// It returns a *ValidationError listing all the invalid fields.
+// Invalid rules are programming errors, so they're returned as plain errors.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("yarf: validate target must be a struct, %T found", v)
+	}
+
+	var fields []FieldError
+	if err := validateStruct(rv, "", &fields); err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		return ErrorValidation(fields...)
+	}
+
+	return nil
+}
+
+// Validate is a wrapper for Validate(v)
+func (c *Context) Validate(v interface{}) error {
+	return Validate(v)
+}
+
+// validateStruct checks all the struct fields, adding the failures to the list.
+func validateStruct(rv reflect.Value, prefix string, fields *[]FieldError) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		fv := rv.Field(i)
+
+		// Unexported fields, but embedded structs
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		name := prefix + fieldName(f)
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			name = strings.TrimSuffix(prefix, ".")
+		}
+
+		if tag := f.Tag.Get("validate"); tag != "" && tag != "-" {
+			ok, err := validateField(fv, tag, name, fields)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		if err := validateNested(fv, name, fields); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateNested checks the structs contained by a field value.
+func validateNested(fv reflect.Value, name string, fields *[]FieldError) error {
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	switch fv.Kind() {
+	case reflect.Struct:
+		prefix := ""
+		if name != "" {
+			prefix = name + "."
+		}
+		return validateStruct(fv, prefix, fields)
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			if err := validateNested(fv.Index(i), name+"["+strconv.Itoa(i)+"]", fields); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fieldName returns the name used for a field on the error list, from its json tag.
+func fieldName(f reflect.StructField) string {
+	name := f.Tag.Get("json")
+	if i := strings.IndexByte(name, ','); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" || name == "-" {
+		return f.Name
+	}
+
+	return name
+}
+
+// validateField checks a field value against the rules on its tag.
+// It returns false if the field failed any rule.
+func validateField(fv reflect.Value, tag, name string, fields *[]FieldError) (bool, error) {
+	rules := splitRules(tag)
+
+	// Pointers are only empty when nil
+	isPtr := fv.Kind() == reflect.Ptr
+	for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+		fv = fv.Elem()
+	}
+	empty := isEmptyValue(fv)
+	if isPtr {
+		empty = fv.Kind() == reflect.Ptr
+	}
+
+	for _, rule := range rules {
+		key, arg := rule, ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			key, arg = rule[:i], rule[i+1:]
+		}
+
+		// Optional fields are only checked when present
+		if key != "required" && empty {
+			continue
+		}
+		if key == "required" && isPtr && !empty {
+			continue
+		}
+
+		check, ok := validationRules[key]
+		if !ok {
+			return false, fmt.Errorf("yarf: unknown validation rule '%s' on field '%s'", key, name)
+		}
+
+		msg, err := check(fv, arg)
+		if err != nil {
+			return false, fmt.Errorf("yarf: invalid validation rule '%s' on field '%s': %s", rule, name, err)
+		}
+		if msg != "" {
+			*fields = append(*fields, FieldError{Field: name, Rule: rule, Message: msg})
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// splitRules splits a validate tag into rules.
+// The regex rule takes the rest of the tag, so expressions can contain commas.
+func splitRules(tag string) []string {
+	var rules []string
+
+	for tag != "" {
+		if strings.HasPrefix(tag, "regex=") {
+			return append(rules, tag)
+		}
+
+		i := strings.IndexByte(tag, ',')
+		if i < 0 {
+			return append(rules, tag)
+		}
+
+		rules = append(rules, tag[:i])
+		tag = tag[i+1:]
+	}
+
+	return rules
+}
+
+// isEmptyValue returns true for zero values and empty collections.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+
+	return v.IsZero()
+}
+
+// validationRule checks a field value with the rule argument.
+// It returns the failure message, or an empty string if the value is valid.
+type validationRule func(v reflect.Value, arg string) (string, error)
+
+// validationRules stores the available rules by name.
+var validationRules = map[string]validationRule{
+	"required": requiredRule,
+	"min":      minRule,
+	"max":      maxRule,
+	"len":      lenRule,
+	"oneof":    oneofRule,
+	"email":    emailRule,
+	"regex":    regexRule,
+}
+
+// requiredRule fails for empty values.
+func requiredRule(v reflect.Value, arg string) (string, error) {
+	if isEmptyValue(v) {
+		return "is required", nil
+	}
+
+	return "", nil
+}
+
+// minRule checks the minimum value or length.
+func minRule(v reflect.Value, arg string) (string, error) {
+	return compareRule(v, arg, -1)
+}
+
+// maxRule checks the maximum value or length.
+func maxRule(v reflect.Value, arg string) (string, error) {
+	return compareRule(v, arg, 1)
+}
+
+// compareRule checks a value, or its length, against a limit.
+// Direction -1 checks the limit as a minimum, 1 as a maximum.
+func compareRule(v reflect.Value, arg string, direction int) (string, error) {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return "", err
+	}
+
+	bound := "at least"
+	if direction > 0 {
+		bound = "at most"
+	}
+
+	var n float64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+
+	default:
+		l, unit, err := length(v)
+		if err != nil {
+			return "", err
+		}
+		if (direction < 0 && l < limit) || (direction > 0 && l > limit) {
+			return "must have " + bound + " " + arg + " " + unit, nil
+		}
+		return "", nil
+	}
+
+	if (direction < 0 && n < limit) || (direction > 0 && n > limit) {
+		return "must be " + bound + " " + arg, nil
+	}
+
+	return "", nil
+}
+
+// lenRule checks the exact length.
+func lenRule(v reflect.Value, arg string) (string, error) {
+	limit, err := strconv.Atoi(arg)
+	if err != nil {
+		return "", err
+	}
+
+	l, unit, err := length(v)
+	if err != nil {
+		return "", err
+	}
+	if int(l) != limit {
+		return "must have exactly " + arg + " " + unit, nil
+	}
+
+	return "", nil
+}
+
+// length returns the length of strings, in characters, or collections, in items.
+func length(v reflect.Value) (float64, string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), "characters", nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), "items", nil
+	}
+
+	return 0, "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// oneofRule checks the value against a space separated list.
+func oneofRule(v reflect.Value, arg string) (string, error) {
+	options := strings.Fields(arg)
+
+	var value string
+	switch v.Kind() {
+	case reflect.String:
+		value = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = strconv.FormatUint(v.Uint(), 10)
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	for _, o := range options {
+		if value == o {
+			return "", nil
+		}
+	}
+
+	return "must be one of: " + strings.Join(options, ", "), nil
+}
+
+// emailRule checks for a single email address, without display name.
+func emailRule(v reflect.Value, arg string) (string, error) {
+	if v.Kind() != reflect.String {
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	addr, err := mail.ParseAddress(v.String())
+	if err != nil || addr.Address != v.String() {
+		return "must be a valid email address", nil
+	}
+
+	return "", nil
+}
+
+// regexCache stores the compiled regex rules by expression.
+var regexCache sync.Map
+
+// regexRule checks the string against the full regular expression.
+func regexRule(v reflect.Value, arg string) (string, error) {
+	if v.Kind() != reflect.String {
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	re, ok := regexCache.Load(arg)
+	if !ok {
+		compiled, err := regexp.Compile("^(?:" + arg + ")$")
+		if err != nil {
+			return "", err
+		}
+		re, _ = regexCache.LoadOrStore(arg, compiled)
+	}
+
+	if !re.(*regexp.Regexp).MatchString(v.String()) {
+		return "must match " + arg, nil
+	}
+
+	return "", nil
+}
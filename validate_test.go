@@ -0,0 +1,203 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type MockAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip" validate:"len=5,regex=[0-9]+"`
+}
+
+type MockItem struct {
+	Name string `json:"name" validate:"required"`
+	Qty  int    `json:"qty" validate:"min=1,max=10"`
+}
+
+type MockValidUser struct {
+	Name     string       `json:"name" validate:"required,min=2,max=10"`
+	Email    string       `json:"email" validate:"required,email"`
+	Role     string       `json:"role" validate:"oneof=admin user"`
+	Age      *int         `json:"age" validate:"min=18"`
+	Tags     []string     `json:"tags" validate:"max=2"`
+	Code     string       `json:"code" validate:"regex=[a-z]{2,3}"`
+	Address  MockAddress  `json:"address"`
+	Billing  *MockAddress `json:"billing"`
+	Items    []MockItem   `json:"items" validate:"required"`
+	Internal string       `validate:"-"`
+}
+
+func validUser() MockValidUser {
+	return MockValidUser{
+		Name:    "John",
+		Email:   "john@example.com",
+		Role:    "admin",
+		Tags:    []string{"a"},
+		Code:    "ab",
+		Address: MockAddress{City: "Paris", Zip: "75001"},
+		Items:   []MockItem{{Name: "item", Qty: 1}},
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	u := validUser()
+	if err := Validate(&u); err != nil {
+		t.Errorf("Valid struct shouldn't return errors, %s found", err)
+	}
+	if err := Validate(u); err != nil {
+		t.Errorf("Structs can be validated by value, %s found", err)
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	age := 17
+
+	tests := []struct {
+		change func(*MockValidUser)
+		field  string
+		rule   string
+	}{
+		{func(u *MockValidUser) { u.Name = "" }, "name", "required"},
+		{func(u *MockValidUser) { u.Name = "J" }, "name", "min=2"},
+		{func(u *MockValidUser) { u.Name = "Johnathan Smith" }, "name", "max=10"},
+		{func(u *MockValidUser) { u.Email = "john" }, "email", "email"},
+		{func(u *MockValidUser) { u.Email = "John <john@example.com>" }, "email", "email"},
+		{func(u *MockValidUser) { u.Role = "root" }, "role", "oneof=admin user"},
+		{func(u *MockValidUser) { u.Age = &age }, "age", "min=18"},
+		{func(u *MockValidUser) { u.Tags = []string{"a", "b", "c"} }, "tags", "max=2"},
+		{func(u *MockValidUser) { u.Code = "abcd" }, "code", "regex=[a-z]{2,3}"},
+		{func(u *MockValidUser) { u.Address.City = "" }, "address.city", "required"},
+		{func(u *MockValidUser) { u.Address.Zip = "123" }, "address.zip", "len=5"},
+		{func(u *MockValidUser) { u.Billing = &MockAddress{City: "Rome", Zip: "abcde"} }, "billing.zip", "regex=[0-9]+"},
+		{func(u *MockValidUser) { u.Items = nil }, "items", "required"},
+		{func(u *MockValidUser) { u.Items = append(u.Items, MockItem{Name: "x", Qty: 11}) }, "items[1].qty", "max=10"},
+	}
+
+	for _, test := range tests {
+		u := validUser()
+		test.change(&u)
+
+		err := Validate(&u)
+		verr, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("Invalid '%s' should return a *ValidationError, %v found", test.field, err)
+			continue
+		}
+		if len(verr.Fields) != 1 || verr.Fields[0].Field != test.field || verr.Fields[0].Rule != test.rule {
+			t.Errorf("Invalid '%s' should fail rule '%s', %+v found", test.field, test.rule, verr.Fields)
+		}
+	}
+}
+
+func TestValidateOptional(t *testing.T) {
+	u := validUser()
+	u.Role = ""
+	u.Code = ""
+	u.Address.Zip = ""
+	u.Tags = nil
+
+	if err := Validate(&u); err != nil {
+		t.Errorf("Empty optional fields shouldn't be validated, %s found", err)
+	}
+}
+
+type MockPointerFields struct {
+	Count  *int  `json:"count" validate:"required,max=10"`
+	Active *bool `json:"active" validate:"required"`
+}
+
+func TestValidatePointers(t *testing.T) {
+	count, active := 0, false
+	if err := Validate(&MockPointerFields{Count: &count, Active: &active}); err != nil {
+		t.Errorf("Required pointers to zero values should be valid, %s found", err)
+	}
+
+	err := Validate(&MockPointerFields{})
+	verr, ok := err.(*ValidationError)
+	if !ok || len(verr.Fields) != 2 {
+		t.Fatalf("Nil required pointers should fail, %v found", err)
+	}
+
+	count = 11
+	err = Validate(&MockPointerFields{Count: &count, Active: &active})
+	verr, ok = err.(*ValidationError)
+	if !ok || len(verr.Fields) != 1 || verr.Fields[0].Rule != "max=10" {
+		t.Errorf("Rules should check the value pointed to, %v found", err)
+	}
+}
+
+func TestValidateMultipleFields(t *testing.T) {
+	err := Validate(&MockValidUser{})
+
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("Invalid struct should return a *ValidationError, %v found", err)
+	}
+	if len(verr.Fields) != 4 {
+		t.Errorf("All invalid fields should be listed, %+v found", verr.Fields)
+	}
+	if verr.Code() != 422 {
+		t.Errorf("ValidationError should be a 422 error, %d found", verr.Code())
+	}
+	if !strings.Contains(verr.Error(), "name is required") {
+		t.Errorf("Error() should list the field errors, '%s' found", verr.Error())
+	}
+}
+
+type MockBadRuleStruct struct {
+	Name string `validate:"unknown"`
+}
+
+type MockBadArgStruct struct {
+	Name string `validate:"min=abc"`
+}
+
+func TestValidateInvalidRules(t *testing.T) {
+	for _, v := range []interface{}{&MockBadRuleStruct{"a"}, &MockBadArgStruct{"a"}, "string", nil} {
+		err := Validate(v)
+		if err == nil {
+			t.Errorf("Validate(%T) should return an error", v)
+		}
+		if _, ok := err.(YError); ok {
+			t.Errorf("Validate(%T) should return a plain error", v)
+		}
+	}
+}
+
+type MockValidateResource struct {
+	Resource
+}
+
+func (r *MockValidateResource) Post(c *Context) error {
+	var u MockValidUser
+	if err := c.Bind(&u); err != nil {
+		return err
+	}
+
+	return c.Validate(&u)
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	y := New()
+	y.Add("/users", new(MockValidateResource))
+
+	req, _ := http.NewRequest("POST", "http://localhost:8080/users", strings.NewReader(`{"name": "John", "email": "john"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 422 {
+		t.Errorf("Validation errors should return 422, %d found", res.Code)
+	}
+	if !strings.HasPrefix(res.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Validation errors should be JSON, '%s' found", res.Header().Get("Content-Type"))
+	}
+
+	expected := `{"errors":[{"field":"email","rule":"email","message":"must be a valid email address"},{"field":"address.city","rule":"required","message":"is required"},{"field":"items","rule":"required","message":"is required"}]}`
+	if res.Body.String() != expected {
+		t.Errorf("Validation error body should be '%s', '%s' found", expected, res.Body.String())
+	}
+}
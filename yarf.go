@@ -1,32 +1,61 @@
 package yarf
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
 )
 
 // Version string
-const Version = "0.8.5"
+const Version = "0.9.0"
 
 // Yarf is the main entry point for the framework and it centralizes most of the functionality.
 // All configuration actions are handled by this object.
 type Yarf struct {
+	// Routes version of the cached routes, first to keep it 64-bit aligned
+	cacheVersion uint64
+
 	// UseCache indicates if the route cache should be used.
 	UseCache bool
 
 	// Debug enables/disables the debug mode.
-	// On debug mode, extra error information is sent to the client.
+	// On debug mode, extra error information is sent to the client:
+	// a developer error page with the error details, the matched route and the stack trace for panics,
+	// as HTML for browsers or JSON otherwise. It shouldn't be enabled on production.
 	Debug bool
 
+	// ProblemDetails enables RFC 7807 error responses.
+	// Errors are rendered as application/problem+json, or application/problem+xml for clients preferring XML,
+	// instead of writing the YError Body() as it is.
+	ProblemDetails bool
+
 	// PanicHandler can store a func() that will be defered by each request to be able to recover().
-	// If you need to log, send information or do anything about a panic, this is your place.
+	//
+	// Deprecated: panics during the request are recovered by Yarf and sent as 500 responses,
+	// so PanicHandler only gets the panics raised by RecoverHandler. Use RecoverHandler instead.
 	PanicHandler func()
 
+	// RecoverHandler is called with the panic value and stack trace when a panic is recovered during the request flow.
+	// If you need to log, send information or do anything about a panic, this is your place.
+	// The panic is then handled as a PanicError, like any other error.
+	RecoverHandler func(c *Context, v interface{}, stack []byte)
+
 	GroupRouter
 
-	// Cached routes storage
-	cache *Cache
+	// Cache stores the matched routes by request path when UseCache is enabled.
+	// It's automatically cleared when routes are added after the first request.
+	Cache Cache
+
+	// MaxBodySize limits the amount of bytes read from the request body by Context.Bind().
+	// DefaultMaxBodySize is used when it isn't set.
+	MaxBodySize int64
+
+	// StrictBinding makes Context.Bind() and Context.BindQuery() reject unknown fields.
+	StrictBinding bool
 
 	// Logger object will be used if present
 	Logger *log.Logger
@@ -36,6 +65,14 @@ type Yarf struct {
 
 	// NotFound defines a function interface to execute when a NotFound (404) error is thrown.
 	NotFound func(c *Context)
+
+	// ErrorHandler writes the response for the errors returned during the request flow,
+	// replacing DefaultErrorHandler. It receives the original error, so it can be inspected with errors.Is and errors.As.
+	// Groups can override it with GroupRoute.OnError(), and Yarf.NotFound takes precedence for 404 errors.
+	ErrorHandler func(c *Context, err error)
+
+	// Global middleware
+	middleware []MiddlewareHandler
 }
 
 // New creates a new yarf and returns a pointer to it.
@@ -45,7 +82,7 @@ func New() *Yarf {
 
 	// Init cache
 	y.UseCache = true
-	y.cache = NewCache()
+	y.Cache = NewCache()
 	y.GroupRouter = RouteGroup("")
 
 	// Return object
@@ -57,6 +94,10 @@ func New() *Yarf {
 // If an error is returned by any of the actions, the flow is stopped and a response is sent.
 // If no route matches, tries to forward the request to the Yarf.Follow (http.Handler type) property if set.
 // Otherwise it returns a 404 response.
+// Global middleware runs around all of it, for every request,
+// and net/http middleware added with Yarf.Wrap() runs around the global middleware and the error response.
+// Panics on route matching and dispatch are recovered and sent as PanicError 500 responses, after running the End middleware.
+// Panics on Yarf.Wrap() middleware and error handlers are recovered as well, and sent as 500 responses without body.
 func (y *Yarf) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	if y.PanicHandler != nil {
 		defer y.PanicHandler()
@@ -65,51 +106,159 @@ func (y *Yarf) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	// Set initial context data.
 	// The Context pointer will be affected by the middleware and resources.
 	c := NewContext(req, res)
+	c.yarf = y
+
+	defer y.recoverRequest(c)
+
+	if g, ok := y.GroupRouter.(*GroupRoute); ok && len(g.wrappers) > 0 {
+		g.wrap(c, y.serve)
+	} else {
+		y.serve(c)
+	}
+
+	// Send the status code of responses without body
+	c.writer.WriteHeaderNow()
+}
+
+// recoverRequest handles the panics escaping the request flow, as the ones raised by Yarf.Wrap() middleware
+// or the error handlers, so they don't get to net/http. It has to be deferred by ServeHTTP.
+func (y *Yarf) recoverRequest(c *Context) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	// Let net/http abort the response
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	if y.RecoverHandler != nil {
+		perr := newPanicError(v, debug.Stack())
+		y.RecoverHandler(c, perr.Value, perr.Stack())
+	}
 
+	// The error handlers may be the ones panicking, so the status code is sent as it is.
+	if !c.writer.Written() {
+		c.writer.WriteHeader(http.StatusInternalServerError)
+		c.writer.WriteHeaderNow()
+	}
+}
+
+// serve matches and dispatches the request, and handles the errors returned.
+func (y *Yarf) serve(c *Context) {
+	dispatch := y.dispatch
+	if err := y.matchRoute(c); err != nil {
+		// Routing panics are returned in place of the dispatch, so the global middleware still runs.
+		dispatch = func(*Context) error { return err }
+	}
+
+	err := dispatchMiddleware(y.middleware, c, dispatch)
+	y.finish(c, err)
+}
+
+// matchRoute sets the route matching the request, returning panics as PanicError.
+// Route matching can panic on custom request matchers and Cache implementations.
+func (y *Yarf) matchRoute(c *Context) (err error) {
+	defer recoverPanic(&err)
+
+	c.matched = y.route(c)
+
+	return nil
+}
+
+// route looks for the route matching the request, using the route cache if enabled.
+// It returns false if no route matches.
+func (y *Yarf) route(c *Context) bool {
 	// Cached routes
-	if y.UseCache {
-		if cache, ok := y.cache.Get(req.URL.Path); ok {
-			// Set context params
-			c.Params = cache.params
-			c.groupDispatch = cache.route
+	useCache := y.UseCache && y.Cache != nil
+	var key string
+	var version uint64
+	if useCache {
+		version = y.checkCache()
 
-			// Dispatch and stop
-			err := y.Dispatch(c)
-			y.finish(c, err)
-			return
+		// Routes cached before routes were added are ignored,
+		// even if they were stored after the cache was cleared.
+		key = y.cacheKey(c.Request)
+		if cache, ok := y.Cache.Get(key); ok && cache.version == version {
+			// Set context params
+			cache.restore(c)
+			c.chain = c.groupDispatch
+			return true
 		}
 	}
 
 	// Route match
-	if y.Match(req.URL.Path, c) {
-		if y.UseCache {
-			y.cache.Set(req.URL.Path, RouteCache{c.groupDispatch, c.Params})
+	if !y.Match(c.Request.URL.Path, c) {
+		return false
+	}
+	c.chain = c.groupDispatch
+
+	if useCache && !c.uncacheable {
+		y.Cache.Set(key, newRouteCache(c, version))
+	}
+
+	return true
+}
+
+// dispatch handles the request after the route matching.
+func (y *Yarf) dispatch(c *Context) error {
+	if c.matched {
+		// The root group wrappers already run around the whole request
+		if g, ok := y.GroupRouter.(*GroupRoute); ok {
+			return g.dispatch(c)
 		}
-		err := y.Dispatch(c)
-		y.finish(c, err)
-		return
+
+		return y.Dispatch(c)
 	}
 
 	// Follow only when route doesn't match.
 	// Returned 404 errors won't follow.
 	if y.Follow != nil {
-		// Log follow
-		y.finish(c, nil)
-
-		// Follow
 		y.Follow.ServeHTTP(c.Response, c.Request)
-
-		// End here
-		return
+		return nil
 	}
 
 	// Return 404
-	y.finish(c, ErrorNotFound())
+	return ErrorNotFound()
+}
+
+// InsertGlobal adds a MiddlewareHandler running for every request,
+// even when no route matches and the request follows to Yarf.Follow or gets a 404 response.
+// Global middleware runs before the route middleware, so Context.Matched() can be used
+// to know if a route matched the request.
+func (y *Yarf) InsertGlobal(m MiddlewareHandler) {
+	y.middleware = append(y.middleware, m)
+}
+
+// checkCache clears the route cache if routes were added since it was filled.
+// It returns the current routes version of the router.
+func (y *Yarf) checkCache() uint64 {
+	var v uint64
+	if g, ok := y.GroupRouter.(*GroupRoute); ok {
+		v = g.routesVersion()
+	}
+	old := atomic.LoadUint64(&y.cacheVersion)
+
+	if old != v && atomic.CompareAndSwapUint64(&y.cacheVersion, old, v) {
+		y.Cache.Clear()
+	}
+
+	return v
+}
+
+// cacheKey returns the route cache key for a request.
+// When routes are restricted to host patterns, the key includes the request host.
+func (y *Yarf) cacheKey(req *http.Request) string {
+	if g, ok := y.GroupRouter.(*GroupRoute); ok && g.compile().hosts {
+		return strings.Join(hostParts(req.Host), ".") + req.URL.Path
+	}
+
+	return req.URL.Path
 }
 
 // Finish handles the end of the execution.
 // It checks for errors and follow actions to execute.
-// It also handles the custom 404 error handler.
 func (y *Yarf) finish(c *Context, err error) {
 	// If a logger is present, lets log everything.
 	if y.Logger != nil {
@@ -123,15 +272,11 @@ func (y *Yarf) finish(c *Context, err error) {
 		// Check for errors
 		errorMsg := "OK"
 		if err != nil {
-			yerr, ok := err.(YError)
-			if ok {
-				if yerr.Code() == 404 && y.NotFound != nil {
-					errorMsg = "FOLLOW NotFound"
-				} else {
-					errorMsg = fmt.Sprintf("ERROR: %d - %s | %s", yerr.Code(), yerr.Body(), yerr.Msg())
-				}
+			yerr := AsYError(err)
+			if yerr.Code() == 404 && y.NotFound != nil {
+				errorMsg = "FOLLOW NotFound"
 			} else {
-				errorMsg = "ERROR: " + err.Error()
+				errorMsg = fmt.Sprintf("ERROR: %d - %s | %s", yerr.Code(), yerr.Body(), err.Error())
 			}
 		}
 
@@ -150,24 +295,76 @@ func (y *Yarf) finish(c *Context, err error) {
 		return
 	}
 
-	// Check error type
-	yerr, ok := err.(YError)
-	if !ok {
-		// Create default 500 error
-		yerr = &CustomError{
-			HTTPCode:  500,
-			ErrorCode: 0,
-			ErrorMsg:  err.Error(),
-			ErrorBody: err.Error(),
+	// Recovered panics
+	var perr *PanicError
+	if y.RecoverHandler != nil && errors.As(err, &perr) {
+		y.RecoverHandler(c, perr.Value, perr.Stack())
+	}
+
+	y.handleError(c, err)
+}
+
+// handleError writes the error response.
+// Errors are handled by the matched groups error handler, the custom 404 error handler,
+// Yarf.ErrorHandler or DefaultErrorHandler, in that order.
+func (y *Yarf) handleError(c *Context, err error) {
+	// The error was already handled inside the net/http middleware wrappers of a group.
+	if c.errorHandled {
+		return
+	}
+	c.errorHandled = true
+
+	// The response was already sent, so the error can't be written anymore.
+	if c.writer != nil && c.writer.Written() {
+		return
+	}
+
+	// Group error handlers
+	for _, r := range c.chain {
+		if g, ok := r.(*GroupRoute); ok && g.errorHandler != nil {
+			g.errorHandler(c, err)
+			return
 		}
 	}
 
 	// Custom 404
-	if yerr.Code() == 404 && y.NotFound != nil {
+	if y.NotFound != nil && AsYError(err).Code() == 404 {
 		y.NotFound(c)
 		return
 	}
 
+	if y.ErrorHandler != nil {
+		y.ErrorHandler(c, err)
+		return
+	}
+
+	y.DefaultErrorHandler(c, err)
+}
+
+// DefaultErrorHandler writes the error response used when there is no custom ErrorHandler.
+// Errors not implementing YError are sent as 500 responses without body.
+// With Yarf.Debug enabled the response is the developer error page.
+// With Yarf.ProblemDetails enabled the response is a RFC 7807 problem details object,
+// otherwise it's the YError Body().
+// Custom error handlers can use it as fallback for the errors they don't handle.
+func (y *Yarf) DefaultErrorHandler(c *Context, err error) {
+	// Developer error page
+	if y.Debug && y.renderDebug(c, err) == nil {
+		return
+	}
+
+	yerr := AsYError(err)
+
+	// RFC 7807 problem details
+	if y.ProblemDetails && c.RenderProblem(yerr) == nil {
+		return
+	}
+
+	// Validation errors are rendered as JSON
+	if _, ok := yerr.(*ValidationError); ok {
+		c.Response.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+
 	// Write error data to response.
 	c.Response.WriteHeader(yerr.Code())
 	c.Render(yerr.Body())
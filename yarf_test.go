@@ -1,8 +1,13 @@
 package yarf
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -17,8 +22,8 @@ type MockMiddleware struct {
 func TestYarfCache(t *testing.T) {
 	y := New()
 
-	if len(y.cache.storage) > 0 {
-		t.Error("yarf.cache.storage should be empty after initialization")
+	if y.Cache.(*LRUCache).Len() > 0 {
+		t.Error("yarf.Cache should be empty after initialization")
 	}
 
 	r := new(MockResource)
@@ -28,23 +33,23 @@ func TestYarfCache(t *testing.T) {
 	res := httptest.NewRecorder()
 	y.ServeHTTP(res, req)
 
-	if len(y.cache.storage) > 0 {
-		t.Error("yarf.cache.storage should be empty after non-matching request")
+	if y.Cache.(*LRUCache).Len() > 0 {
+		t.Error("yarf.Cache should be empty after non-matching request")
 	}
 
 	req, _ = http.NewRequest("GET", "http://localhost:8080/test", nil)
 	y.ServeHTTP(res, req)
 
-	if len(y.cache.storage) != 1 {
-		t.Error("yarf.cache.storage should have 1 item after matching request")
+	if y.Cache.(*LRUCache).Len() != 1 {
+		t.Error("yarf.Cache should have 1 item after matching request")
 	}
 
 	for i := 0; i < 100; i++ {
 		y.ServeHTTP(res, req)
 	}
 
-	if len(y.cache.storage) != 1 {
-		t.Error("yarf.cache.storage should have 1 item after multiple matching requests to a single route")
+	if y.Cache.(*LRUCache).Len() != 1 {
+		t.Error("yarf.Cache should have 1 item after multiple matching requests to a single route")
 	}
 }
 
@@ -58,8 +63,8 @@ func TestYarfUseCacheFalse(t *testing.T) {
 	res := httptest.NewRecorder()
 	y.ServeHTTP(res, req)
 
-	if len(y.cache.storage) > 0 {
-		t.Error("yarf.cache.storage should be empty after matching request with yarf.UseCache = false")
+	if y.Cache.(*LRUCache).Len() > 0 {
+		t.Error("yarf.Cache should be empty after matching request with yarf.UseCache = false")
 	}
 }
 
@@ -97,3 +102,409 @@ func TestNotFoundResponse(t *testing.T) {
 		t.Error("Non matching route should return 404 response")
 	}
 }
+
+type MockParamsMiddleware struct {
+	Middleware
+}
+
+func (m *MockParamsMiddleware) PreDispatch(c *Context) error {
+	c.Params.Set("id", c.Request.Header.Get("X-Id"))
+	c.Params.Set("extra", "value")
+	return nil
+}
+
+type MockParamsResource struct {
+	Resource
+}
+
+func (r *MockParamsResource) Get(c *Context) error {
+	if c.Param("id") != c.Request.Header.Get("X-Id") {
+		c.Status(500)
+	}
+	return nil
+}
+
+func TestCacheParamsRace(t *testing.T) {
+	y := New()
+	y.Insert(new(MockParamsMiddleware))
+	y.Add("/users/:id", new(MockParamsResource))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				req, _ := http.NewRequest("GET", "http://localhost:8080/users/1", nil)
+				req.Header.Set("X-Id", strconv.Itoa(i))
+				res := httptest.NewRecorder()
+				y.ServeHTTP(res, req)
+
+				if res.Code != 200 {
+					t.Error("Params changed by a request shouldn't be seen by others")
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	cache, _ := y.Cache.Get("/users/1")
+	if len(cache.params) != 1 || cache.params.Get("id") != "1" {
+		t.Errorf("Cached params shouldn't be changed by requests, %v found", cache.params)
+	}
+}
+
+type MockMatchedMiddleware struct {
+	Middleware
+	log *[]string
+}
+
+func (m *MockMatchedMiddleware) PreDispatch(c *Context) error {
+	*m.log = append(*m.log, "pre:"+strconv.FormatBool(c.Matched()))
+	return nil
+}
+
+func (m *MockMatchedMiddleware) End(c *Context) error {
+	*m.log = append(*m.log, "end")
+	return nil
+}
+
+func TestGlobalMiddleware(t *testing.T) {
+	var log []string
+
+	y := New()
+	y.InsertGlobal(&MockMatchedMiddleware{log: &log})
+	y.Add("/test", new(MockResource))
+
+	tests := []struct {
+		url    string
+		follow http.Handler
+		log    string
+		code   int
+	}{
+		{"/test", nil, "pre:true end", 405},
+		{"/test", nil, "pre:true end", 405}, // Cached
+		{"/nothing", nil, "pre:false end", 404},
+		{"/nothing", http.NotFoundHandler(), "pre:false end", 404},
+		{"/nothing", http.RedirectHandler("/test", 302), "pre:false end", 302},
+	}
+
+	for _, test := range tests {
+		log = nil
+		y.Follow = test.follow
+
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+test.url, nil)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if strings.Join(log, " ") != test.log {
+			t.Errorf("Global middleware for '%s' should log '%s', '%s' found", test.url, test.log, strings.Join(log, " "))
+		}
+		if res.Code != test.code {
+			t.Errorf("Request to '%s' should return %d, %d found", test.url, test.code, res.Code)
+		}
+	}
+}
+
+type MockErrorResource struct {
+	Resource
+	err error
+}
+
+func (r *MockErrorResource) Get(c *Context) error {
+	return r.err
+}
+
+var errMockCause = errors.New("cause")
+
+func TestErrorHandler(t *testing.T) {
+	wrapped := fmt.Errorf("wrapped: %w", errMockCause)
+
+	admin := RouteGroup("/admin")
+	admin.OnError(func(c *Context, err error) {
+		c.Status(500)
+		c.Render("admin: " + err.Error())
+	})
+	admin.Add("/fail", &MockErrorResource{err: wrapped})
+
+	nested := RouteGroup("/nested")
+	nested.Add("/fail", &MockErrorResource{err: ErrorNotFound()})
+	admin.AddGroup(nested)
+
+	y := New()
+	y.ErrorHandler = func(c *Context, err error) {
+		if errors.Is(err, errMockCause) {
+			c.Status(503)
+			c.Render("cause")
+			return
+		}
+		y.DefaultErrorHandler(c, err)
+	}
+	y.NotFound = func(c *Context) {
+		c.Status(404)
+		c.Render("not found")
+	}
+	y.AddGroup(admin)
+	y.Add("/fail", &MockErrorResource{err: wrapped})
+	y.Add("/conflict", &MockErrorResource{err: &CustomError{HTTPCode: 409, ErrorBody: "conflict"}})
+
+	tests := []struct {
+		url  string
+		code int
+		body string
+	}{
+		{"/fail", 503, "cause"},
+		{"/conflict", 409, "conflict"},
+		{"/nothing", 404, "not found"},
+		{"/admin/fail", 500, "admin: wrapped: cause"},
+		{"/admin/fail", 500, "admin: wrapped: cause"}, // Cached
+		{"/admin/nested/fail", 500, "admin: Not found"},
+		{"/admin/nothing", 404, "not found"},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+test.url, nil)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Code != test.code || res.Body.String() != test.body {
+			t.Errorf("Request to '%s' should return %d '%s', %d '%s' found", test.url, test.code, test.body, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestWrappedYErrorResponse(t *testing.T) {
+	y := New()
+	y.Add("/wrapped", &MockErrorResource{err: fmt.Errorf("checking token: %w", ErrorUnauthorized(errMockCause))})
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/wrapped", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 401 {
+		t.Errorf("Wrapped YErrors should keep their status code, %d found", res.Code)
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	var log []string
+	var recovered interface{}
+	var stack []byte
+
+	g := RouteGroup("/api")
+	g.Insert(&MockLogMiddleware{name: "group", log: &log})
+	g.Add("/panic", Handlers{
+		"GET": func(c *Context) error {
+			panic("resource panic")
+		},
+	})
+	g.Add("/written", Handlers{
+		"GET": func(c *Context) error {
+			c.Render("partial")
+			panic(errMockCause)
+		},
+	})
+
+	y := New()
+	y.InsertGlobal(&MockLogMiddleware{name: "global", log: &log})
+	y.RecoverHandler = func(c *Context, v interface{}, s []byte) {
+		recovered, stack = v, s
+	}
+	y.AddGroup(g)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/api/panic", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 500 {
+		t.Errorf("Panics should return 500, %d found", res.Code)
+	}
+	if recovered != "resource panic" || !strings.Contains(string(stack), "TestPanicRecovery") {
+		t.Errorf("RecoverHandler should get the panic value and stack, '%v' found", recovered)
+	}
+	if strings.Join(log, " ") != "global-pre group-pre group-end global-end" {
+		t.Errorf("End middleware should run after panics, %v found", log)
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/api/written", nil)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 200 || res.Body.String() != "partial" {
+		t.Errorf("Panics after writing the response shouldn't change it, %d '%s' found", res.Code, res.Body.String())
+	}
+	if recovered != errMockCause {
+		t.Errorf("RecoverHandler should be called for written responses, '%v' found", recovered)
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	y := New()
+
+	var got error
+	y.ErrorHandler = func(c *Context, err error) {
+		got = err
+		y.DefaultErrorHandler(c, err)
+	}
+	y.Add("/middleware", new(MockResource), Use(new(MockPanicMiddleware)))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/middleware", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	var perr *PanicError
+	if !errors.As(got, &perr) || !errors.Is(got, errMockCause) {
+		t.Fatalf("Middleware panics should be sent to the error handler as PanicError wrapping the panic value, %v found", got)
+	}
+	if perr.Code() != 500 || perr.Body() != "" || len(perr.Stack()) == 0 {
+		t.Errorf("PanicError should be a 500 error with stack and without body, %d '%s' found", perr.Code(), perr.Body())
+	}
+	if res.Code != 500 {
+		t.Errorf("Middleware panics should return 500, %d found", res.Code)
+	}
+}
+
+type MockPanicMiddleware struct {
+	Middleware
+}
+
+func (m *MockPanicMiddleware) PreDispatch(c *Context) error {
+	panic(errMockCause)
+}
+
+func TestPanicAbortHandler(t *testing.T) {
+	y := New()
+	y.Add("/abort", Handlers{
+		"GET": func(c *Context) error {
+			panic(http.ErrAbortHandler)
+		},
+	})
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("http.ErrAbortHandler panics should reach net/http")
+		}
+	}()
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/abort", nil)
+	y.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+type MockPanicCache struct {
+	Cache
+}
+
+func (c MockPanicCache) Get(key string) (RouteCache, bool) {
+	panic("cache panic")
+}
+
+type MockPanicEndMiddleware struct {
+	Middleware
+}
+
+func (m *MockPanicEndMiddleware) End(c *Context) error {
+	panic("end panic")
+}
+
+func TestPanicOutsideDispatch(t *testing.T) {
+	var log []string
+	var recovered []interface{}
+
+	y := New()
+	y.InsertGlobal(&MockLogMiddleware{name: "global", log: &log})
+	y.RecoverHandler = func(c *Context, v interface{}, stack []byte) {
+		recovered = append(recovered, v)
+	}
+	y.Add("/matcher", new(MockResource), When(func(r *http.Request) bool {
+		panic("matcher panic")
+	}))
+	y.Add("/end", Handlers{"GET": func(c *Context) error { return nil }}, Use(new(MockPanicEndMiddleware)))
+
+	tests := []struct {
+		url   string
+		cache Cache
+		panic string
+	}{
+		{"/matcher", NewCache(), "matcher panic"},
+		{"/end", NewCache(), "end panic"},
+		{"/end", MockPanicCache{}, "cache panic"},
+	}
+
+	for _, test := range tests {
+		log, recovered = nil, nil
+		y.Cache = test.cache
+
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+test.url, nil)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Code != 500 {
+			t.Errorf("Panic '%s' should return 500, %d found", test.panic, res.Code)
+		}
+		if len(recovered) != 1 || recovered[0] != test.panic {
+			t.Errorf("RecoverHandler should get '%s', %v found", test.panic, recovered)
+		}
+		if strings.Join(log, " ") != "global-pre global-end" {
+			t.Errorf("Global middleware should run for panic '%s', %v found", test.panic, log)
+		}
+	}
+
+	// Panics on global End
+	y = New()
+	y.InsertGlobal(new(MockPanicEndMiddleware))
+	y.Add("/test", Handlers{"GET": func(c *Context) error { return nil }})
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/test", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 500 {
+		t.Errorf("Panics on global End should return 500, %d found", res.Code)
+	}
+}
+
+func TestPanicServeHTTP(t *testing.T) {
+	var recovered []interface{}
+
+	y := New()
+	y.RecoverHandler = func(c *Context, v interface{}, stack []byte) {
+		recovered = append(recovered, v)
+	}
+	y.Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/wrap" {
+				panic("wrap panic")
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	y.ErrorHandler = func(c *Context, err error) {
+		panic("error handler panic")
+	}
+	y.Add("/wrap", new(MockResource))
+
+	tests := []struct {
+		url   string
+		panic string
+	}{
+		{"/wrap", "wrap panic"},
+		{"/missing", "error handler panic"},
+	}
+
+	for _, test := range tests {
+		recovered = nil
+
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+test.url, nil)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Code != 500 {
+			t.Errorf("Panic '%s' should return 500, %d found", test.panic, res.Code)
+		}
+		if len(recovered) != 1 || recovered[0] != test.panic {
+			t.Errorf("RecoverHandler should get '%s', %v found", test.panic, recovered)
+		}
+	}
+}